@@ -1,6 +1,7 @@
 package arn
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 )
@@ -25,3 +26,128 @@ func ParseStreamArn(streamArn string) StreamIdentifier {
 func CreateVirtualArn(groupName, streamName string) string {
 	return fmt.Sprintf("_:log-group:%s:log-stream:%s", groupName, streamName)
 }
+
+// ParseResource identifies the log group or stream a line of pipeline input
+// refers to. It accepts every format cwl writes: stream and group ARNs, JSON
+// objects such as `cwl streams --json` output (keys are matched
+// case-insensitively, so both "arn" and "Arn" work), tab-separated listings
+// such as `cwl groups --all-profiles` whose last column is the group name,
+// and bare group names. StreamName is empty when the line names a whole
+// group. `--long` tables need their header row to be read, so use a Parser
+// for those.
+func ParseResource(line string) (StreamIdentifier, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return StreamIdentifier{}, fmt.Errorf("empty line")
+	}
+	if strings.HasPrefix(line, "{") {
+		return parseJSONResource(line)
+	}
+	if i := strings.LastIndex(line, "\t"); i >= 0 {
+		line = line[i+1:]
+	}
+	if strings.Contains(line, ":log-group:") {
+		return parseArn(line), nil
+	}
+	return StreamIdentifier{GroupName: line}, nil
+}
+
+// Parser parses pipeline input line by line like ParseResource, and also
+// reads the space-aligned tables `cwl groups --long` and `cwl streams --long`
+// print: their header row says which columns hold the group and stream
+// names, and the rows are split on runs of spaces (so a stream name with a
+// space in it needs --json instead).
+type Parser struct {
+	group, stream int // column indexes from the last header, -1 if absent
+	table         bool
+}
+
+// tableHeaders maps the header of a column holding a group or stream name
+// to whether it is the stream column.
+var tableHeaders = map[string]bool{"NAME": false, "GROUP": false, "STREAM": true}
+
+// Parse returns the resource a line refers to. ok is false for a table's
+// header row, which names nothing.
+func (p *Parser) Parse(line string) (id StreamIdentifier, ok bool, err error) {
+	fields := strings.Fields(line)
+	if p.header(fields) {
+		return StreamIdentifier{}, false, nil
+	}
+	trimmed := strings.TrimSpace(line)
+	if !p.table || trimmed == "" || strings.HasPrefix(trimmed, "{") || strings.Contains(line, "\t") {
+		id, err := ParseResource(line)
+		return id, err == nil, err
+	}
+	if p.group >= len(fields) || p.stream >= len(fields) {
+		return StreamIdentifier{}, false, fmt.Errorf("table row has too few columns: %s", trimmed)
+	}
+	id.GroupName = fields[p.group]
+	if p.stream >= 0 {
+		id.StreamName = fields[p.stream]
+	}
+	return id, true, nil
+}
+
+// header records the column layout if fields are a table's header row: all
+// upper case, with a group name column.
+func (p *Parser) header(fields []string) bool {
+	if len(fields) < 2 {
+		return false
+	}
+	group, stream := -1, -1
+	for i, f := range fields {
+		if strings.ToUpper(f) != f {
+			return false
+		}
+		isStream, ok := tableHeaders[f]
+		switch {
+		case !ok:
+		case isStream && stream < 0:
+			stream = i
+		case !isStream && group < 0:
+			group = i
+		}
+	}
+	if group < 0 {
+		return false
+	}
+	p.group, p.stream, p.table = group, stream, true
+	return true
+}
+
+func parseJSONResource(line string) (StreamIdentifier, error) {
+	var fields struct {
+		Arn           string
+		LogGroupArn   string
+		LogGroupName  string
+		LogStreamName string
+	}
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return StreamIdentifier{}, fmt.Errorf("invalid JSON input: %w", err)
+	}
+	id := StreamIdentifier{GroupName: fields.LogGroupName, StreamName: fields.LogStreamName}
+	for _, a := range []string{fields.Arn, fields.LogGroupArn} {
+		if id.GroupName != "" {
+			break
+		}
+		if strings.Contains(a, ":log-group:") {
+			fromArn := parseArn(a)
+			id.GroupName = fromArn.GroupName
+			if id.StreamName == "" {
+				id.StreamName = fromArn.StreamName
+			}
+		}
+	}
+	if id.GroupName == "" {
+		return StreamIdentifier{}, fmt.Errorf("JSON input has no arn or logGroupName: %s", line)
+	}
+	return id, nil
+}
+
+// parseArn is ParseStreamArn that also accepts group ARNs, with or without
+// the trailing ":*".
+func parseArn(a string) StreamIdentifier {
+	rest := strings.SplitN(a, ":log-group:", 2)[1]
+	groupName, streamName, _ := strings.Cut(rest, ":log-stream:")
+	return StreamIdentifier{GroupName: strings.TrimSuffix(groupName, ":*"), StreamName: streamName}
+}
@@ -83,3 +83,133 @@ func TestCreateVirtualArn(t *testing.T) {
 		})
 	}
 }
+
+func TestParseResource(t *testing.T) {
+	tests := []struct {
+		name           string
+		line           string
+		expectedGroup  string
+		expectedStream string
+	}{
+		{
+			name:           "stream ARN",
+			line:           "arn:aws:logs:us-west-2:123:log-group:/my/group:log-stream:my-stream",
+			expectedGroup:  "/my/group",
+			expectedStream: "my-stream",
+		},
+		{
+			name:          "group ARN with wildcard",
+			line:          "arn:aws:logs:us-west-2:123:log-group:/my/group:*",
+			expectedGroup: "/my/group",
+		},
+		{
+			name:          "bare group name",
+			line:          "/aws/lambda/my-function",
+			expectedGroup: "/aws/lambda/my-function",
+		},
+		{
+			name:          "tab-separated listing",
+			line:          "default\tus-east-1\t123\t/my/group",
+			expectedGroup: "/my/group",
+		},
+		{
+			name:           "streams --json output",
+			line:           `{"Arn":"arn:aws:logs:us-west-2:123:log-group:/my/group:log-stream:s1","LogStreamName":"s1"}`,
+			expectedGroup:  "/my/group",
+			expectedStream: "s1",
+		},
+		{
+			name:          "groups --json output",
+			line:          `{"Arn":"arn:aws:logs:us-west-2:123:log-group:/my/group:*","LogGroupName":"/my/group"}`,
+			expectedGroup: "/my/group",
+		},
+		{
+			name:           "lower-case keys from jq",
+			line:           `{"logGroupName":"/my/group","logStreamName":"s2"}`,
+			expectedGroup:  "/my/group",
+			expectedStream: "s2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseResource(tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.GroupName != tt.expectedGroup || result.StreamName != tt.expectedStream {
+				t.Errorf("Expected %q/%q, got %q/%q", tt.expectedGroup, tt.expectedStream, result.GroupName, result.StreamName)
+			}
+		})
+	}
+
+	for _, line := range []string{"", "{not json", `{"message":"hello"}`} {
+		if _, err := ParseResource(line); err == nil {
+			t.Errorf("Expected error for %q", line)
+		}
+	}
+}
+
+// TestParserReadsLongTables verifies that a Parser skips the header of a
+// --long table and takes the group and stream names from the columns it
+// names, while other lines are parsed as ParseResource would.
+func TestParserReadsLongTables(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		lines []string
+		want  []StreamIdentifier
+	}{
+		{
+			name: "groups --long",
+			lines: []string{
+				"NAME        STORED  RETENTION  CREATED     CLASS     KMS  DATA PROTECTION",
+				"/my/group   1.5KB   30d        2024-01-02  STANDARD  -    -",
+			},
+			want: []StreamIdentifier{{GroupName: "/my/group"}},
+		},
+		{
+			name: "groups --long across profiles",
+			lines: []string{
+				"PROFILE  REGION     ACCOUNT  NAME       STORED",
+				"prod     us-west-2  123      /my/group  0B",
+			},
+			want: []StreamIdentifier{{GroupName: "/my/group"}},
+		},
+		{
+			name: "streams --long",
+			lines: []string{
+				"GROUP      STREAM  FIRST EVENT           LAST EVENT            STORED  AGE",
+				"/my/group  s1      2024-01-02T15:04:05Z  2024-01-02T15:04:05Z  10B     3d",
+				"/my/group  s2      -                     -                     0B      -",
+			},
+			want: []StreamIdentifier{{GroupName: "/my/group", StreamName: "s1"}, {GroupName: "/my/group", StreamName: "s2"}},
+		},
+		{
+			name:  "without a header",
+			lines: []string{"/aws/lambda/fn", "default\tus-east-1\t123\t/my/group"},
+			want:  []StreamIdentifier{{GroupName: "/aws/lambda/fn"}, {GroupName: "/my/group"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Parser
+			var got []StreamIdentifier
+			for _, line := range tt.lines {
+				id, ok, err := p.Parse(line)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ok {
+					got = append(got, id)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("line %d: got %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
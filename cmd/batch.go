@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/derricw/cwl/interfaces"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// PutLogEvents limits. See:
+// https://docs.aws.amazon.com/AmazonCloudWatchLogs/latest/APIReference/API_PutLogEvents.html
+const (
+	maxBatchEvents   = 10000
+	maxBatchBytes    = 1048576 // 1 MiB, including eventOverhead per event
+	eventOverhead    = 26      // bytes the API adds to each event when sizing a batch
+	maxBatchSpan     = 24 * time.Hour
+	maxEventSize     = 262144 // 256 KiB per event
+	maxPutRetries    = 5
+	defaultFlushTime = 5 * time.Second
+)
+
+// putRetryDelay is the base unit for exponential backoff on throttling.
+// It is a var so tests can set it to 0.
+var putRetryDelay = 500 * time.Millisecond
+
+// eventBatch accumulates events until one of the PutLogEvents limits would
+// be exceeded by the next event.
+type eventBatch struct {
+	events []types.InputLogEvent
+	bytes  int
+	minTs  int64
+	maxTs  int64
+}
+
+func eventSize(e types.InputLogEvent) int {
+	return len(aws.ToString(e.Message)) + eventOverhead
+}
+
+// fits reports whether e can be added without exceeding the event count,
+// byte size, or 24h span limits. An empty batch accepts anything so that a
+// single oversized event is passed to the API (and rejected there) rather
+// than stalling the upload forever.
+func (b *eventBatch) fits(e types.InputLogEvent) bool {
+	if len(b.events) == 0 {
+		return true
+	}
+	if len(b.events) >= maxBatchEvents || b.bytes+eventSize(e) > maxBatchBytes {
+		return false
+	}
+	ts := aws.ToInt64(e.Timestamp)
+	lo, hi := min(b.minTs, ts), max(b.maxTs, ts)
+	return time.Duration(hi-lo)*time.Millisecond < maxBatchSpan
+}
+
+func (b *eventBatch) add(e types.InputLogEvent) {
+	ts := aws.ToInt64(e.Timestamp)
+	if len(b.events) == 0 {
+		b.minTs, b.maxTs = ts, ts
+	} else {
+		b.minTs, b.maxTs = min(b.minTs, ts), max(b.maxTs, ts)
+	}
+	b.events = append(b.events, e)
+	b.bytes += eventSize(e)
+}
+
+func (b *eventBatch) reset() {
+	b.events = nil
+	b.bytes = 0
+}
+
+// putEvents uploads events from a channel to a log stream, batching them up
+// to the PutLogEvents limits. A partial batch is flushed every flushInterval
+// so that slow streaming input (e.g. `tail -f | cwl put`) still shows up
+// promptly. Returns once the channel is closed and the final batch is sent.
+func putEvents(ctx context.Context, client interfaces.CloudWatchLogsClient, groupName, streamName string, events <-chan types.InputLogEvent, flushInterval time.Duration) error {
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushTime
+	}
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var batch eventBatch
+	flush := func() error {
+		if len(batch.events) == 0 {
+			return nil
+		}
+		err := sendBatch(ctx, client, groupName, streamName, batch.events)
+		batch.reset()
+		return err
+	}
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return flush()
+			}
+			if !batch.fits(e) {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			batch.add(e)
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// sendBatch sorts events chronologically (as PutLogEvents requires) and
+// uploads them, retrying with exponential backoff when throttled.
+// Events rejected by the API are reported on stderr.
+func sendBatch(ctx context.Context, client interfaces.CloudWatchLogsClient, groupName, streamName string, events []types.InputLogEvent) error {
+	sort.SliceStable(events, func(i, j int) bool {
+		return aws.ToInt64(events[i].Timestamp) < aws.ToInt64(events[j].Timestamp)
+	})
+	input := &cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String(groupName),
+		LogStreamName: aws.String(streamName),
+		LogEvents:     events,
+	}
+	var err error
+	for attempt := 0; attempt <= maxPutRetries; attempt++ {
+		if attempt > 0 {
+			delay := putRetryDelay * time.Duration(1<<(attempt-1)) // 1x, 2x, 4x, ...
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		var output *cloudwatchlogs.PutLogEventsOutput
+		output, err = client.PutLogEvents(ctx, input)
+		if err == nil {
+			if output != nil {
+				reportRejected(os.Stderr, output.RejectedLogEventsInfo)
+			}
+			return nil
+		}
+		if !isThrottle(err) {
+			return err
+		}
+		if attempt < maxPutRetries {
+			log.Printf("PutLogEvents throttled, retrying (%d/%d)", attempt+1, maxPutRetries)
+		}
+	}
+	return err
+}
+
+// isThrottle reports whether err is worth retrying after a backoff.
+func isThrottle(err error) bool {
+	var throttled *types.ThrottlingException
+	var unavailable *types.ServiceUnavailableException
+	return errors.As(err, &throttled) || errors.As(err, &unavailable)
+}
+
+// reportRejected writes a summary of events the API refused to accept.
+// Indices refer to positions in the (sorted) batch that was sent.
+func reportRejected(w io.Writer, info *types.RejectedLogEventsInfo) {
+	if info == nil {
+		return
+	}
+	if info.TooOldLogEventEndIndex != nil {
+		fmt.Fprintf(w, "rejected events before index %d: too old\n", *info.TooOldLogEventEndIndex)
+	}
+	if info.ExpiredLogEventEndIndex != nil {
+		fmt.Fprintf(w, "rejected events up to index %d: older than the retention period\n", *info.ExpiredLogEventEndIndex)
+	}
+	if info.TooNewLogEventStartIndex != nil {
+		fmt.Fprintf(w, "rejected events from index %d onward: too new\n", *info.TooNewLogEventStartIndex)
+	}
+}
@@ -0,0 +1,318 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// mockPutClient records PutLogEvents calls. The first throttles calls fail
+// with a ThrottlingException. Describe calls return one page per slice
+// element, so exact-match lookups can be tested across pagination.
+type mockPutClient struct {
+	mu        sync.Mutex
+	batches   [][]types.InputLogEvent
+	batchDest []string // stream name of each batch
+	throttles int
+	calls     int
+
+	groupPages     [][]types.LogGroup
+	streamPages    [][]types.LogStream
+	streamCalls    int
+	createdGroups  []*cloudwatchlogs.CreateLogGroupInput
+	createdStreams []string
+	retention      map[string]int32
+	tags           map[string]map[string]string      // by resource ARN
+	events         map[string][]types.OutputLogEvent // by stream name
+	tagLookups     int
+}
+
+func (m *mockPutClient) PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if m.calls <= m.throttles {
+		return nil, &types.ThrottlingException{Message: aws.String("Rate exceeded")}
+	}
+	batch := make([]types.InputLogEvent, len(params.LogEvents))
+	copy(batch, params.LogEvents)
+	m.batches = append(m.batches, batch)
+	m.batchDest = append(m.batchDest, *params.LogStreamName)
+	return &cloudwatchlogs.PutLogEventsOutput{}, nil
+}
+
+func (m *mockPutClient) DescribeLogGroups(ctx context.Context, params *cloudwatchlogs.DescribeLogGroupsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	return &cloudwatchlogs.DescribeLogGroupsOutput{
+		LogGroups: page(m.groupPages, params.NextToken),
+		NextToken: nextPageToken(len(m.groupPages), params.NextToken),
+	}, nil
+}
+func (m *mockPutClient) DescribeLogStreams(ctx context.Context, params *cloudwatchlogs.DescribeLogStreamsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.streamCalls++
+	return &cloudwatchlogs.DescribeLogStreamsOutput{
+		LogStreams: page(m.streamPages, params.NextToken),
+		NextToken:  nextPageToken(len(m.streamPages), params.NextToken),
+	}, nil
+}
+
+// GetLogEvents returns a stream's events within the requested time range on
+// the first call, then an empty page with the same token to signal the end.
+func (m *mockPutClient) GetLogEvents(ctx context.Context, params *cloudwatchlogs.GetLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetLogEventsOutput, error) {
+	end := aws.String("end")
+	if params.NextToken != nil {
+		return &cloudwatchlogs.GetLogEventsOutput{NextForwardToken: end}, nil
+	}
+	var events []types.OutputLogEvent
+	for _, e := range m.events[*params.LogStreamName] {
+		if params.StartTime != nil && *e.Timestamp < *params.StartTime {
+			continue
+		}
+		if params.EndTime != nil && *e.Timestamp >= *params.EndTime {
+			continue
+		}
+		events = append(events, e)
+	}
+	return &cloudwatchlogs.GetLogEventsOutput{Events: events, NextForwardToken: end}, nil
+}
+func (m *mockPutClient) CreateLogStream(ctx context.Context, params *cloudwatchlogs.CreateLogStreamInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.createdStreams = append(m.createdStreams, *params.LogStreamName)
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+}
+func (m *mockPutClient) StartQuery(ctx context.Context, params *cloudwatchlogs.StartQueryInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.StartQueryOutput, error) {
+	return nil, nil
+}
+func (m *mockPutClient) GetQueryResults(ctx context.Context, params *cloudwatchlogs.GetQueryResultsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetQueryResultsOutput, error) {
+	return nil, nil
+}
+func (m *mockPutClient) CreateLogGroup(ctx context.Context, params *cloudwatchlogs.CreateLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	m.createdGroups = append(m.createdGroups, params)
+	return &cloudwatchlogs.CreateLogGroupOutput{}, nil
+}
+func (m *mockPutClient) DeleteLogGroup(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error) {
+	return nil, nil
+}
+func (m *mockPutClient) PutRetentionPolicy(ctx context.Context, params *cloudwatchlogs.PutRetentionPolicyInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+	if m.retention == nil {
+		m.retention = map[string]int32{}
+	}
+	m.retention[*params.LogGroupName] = *params.RetentionInDays
+	return &cloudwatchlogs.PutRetentionPolicyOutput{}, nil
+}
+func (m *mockPutClient) DeleteRetentionPolicy(ctx context.Context, params *cloudwatchlogs.DeleteRetentionPolicyInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteRetentionPolicyOutput, error) {
+	return nil, nil
+}
+func (m *mockPutClient) ListTagsForResource(ctx context.Context, params *cloudwatchlogs.ListTagsForResourceInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.ListTagsForResourceOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tagLookups++
+	return &cloudwatchlogs.ListTagsForResourceOutput{Tags: m.tags[*params.ResourceArn]}, nil
+}
+func (m *mockPutClient) TagResource(ctx context.Context, params *cloudwatchlogs.TagResourceInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.TagResourceOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tags == nil {
+		m.tags = map[string]map[string]string{}
+	}
+	if m.tags[*params.ResourceArn] == nil {
+		m.tags[*params.ResourceArn] = map[string]string{}
+	}
+	for k, v := range params.Tags {
+		m.tags[*params.ResourceArn][k] = v
+	}
+	return &cloudwatchlogs.TagResourceOutput{}, nil
+}
+func (m *mockPutClient) UntagResource(ctx context.Context, params *cloudwatchlogs.UntagResourceInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.UntagResourceOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, k := range params.TagKeys {
+		delete(m.tags[*params.ResourceArn], k)
+	}
+	return &cloudwatchlogs.UntagResourceOutput{}, nil
+}
+
+// page returns the page selected by a numeric pagination token.
+func page[T any](pages [][]T, token *string) []T {
+	i := 0
+	if token != nil {
+		fmt.Sscanf(*token, "%d", &i)
+	}
+	if i >= len(pages) {
+		return nil
+	}
+	return pages[i]
+}
+
+func nextPageToken(numPages int, token *string) *string {
+	i := 0
+	if token != nil {
+		fmt.Sscanf(*token, "%d", &i)
+	}
+	if i+1 >= numPages {
+		return nil
+	}
+	return aws.String(fmt.Sprint(i + 1))
+}
+
+func inputEvent(msg string, ts int64) types.InputLogEvent {
+	return types.InputLogEvent{Message: aws.String(msg), Timestamp: aws.Int64(ts)}
+}
+
+// sendAll feeds events through putEvents and returns the recorded batches.
+func sendAll(t *testing.T, client *mockPutClient, events []types.InputLogEvent) [][]types.InputLogEvent {
+	t.Helper()
+	ch := make(chan types.InputLogEvent, len(events))
+	for _, e := range events {
+		ch <- e
+	}
+	close(ch)
+	if err := putEvents(context.Background(), client, "group", "stream", ch, time.Hour); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return client.batches
+}
+
+// TestPutEventsBatchesByCount verifies that a batch is cut at the
+// 10,000-event limit instead of issuing one API call per line.
+func TestPutEventsBatchesByCount(t *testing.T) {
+	events := make([]types.InputLogEvent, maxBatchEvents+1)
+	for i := range events {
+		events[i] = inputEvent("x", 1000)
+	}
+	batches := sendAll(t, &mockPutClient{}, events)
+	if len(batches) != 2 {
+		t.Fatalf("expected 2 batches, got %d", len(batches))
+	}
+	if len(batches[0]) != maxBatchEvents || len(batches[1]) != 1 {
+		t.Fatalf("unexpected batch sizes %d and %d", len(batches[0]), len(batches[1]))
+	}
+}
+
+// TestPutEventsBatchesBySize verifies that the 1 MiB limit, including the
+// 26-byte per-event overhead, is respected.
+func TestPutEventsBatchesBySize(t *testing.T) {
+	msg := strings.Repeat("a", 100*1024-eventOverhead) // exactly 100 KiB per event
+	events := make([]types.InputLogEvent, 11)
+	for i := range events {
+		events[i] = inputEvent(msg, 1000)
+	}
+	batches := sendAll(t, &mockPutClient{}, events)
+	if len(batches) != 2 {
+		t.Fatalf("expected 2 batches, got %d", len(batches))
+	}
+	if len(batches[0]) != 10 {
+		t.Fatalf("expected 10 events in first batch, got %d", len(batches[0]))
+	}
+}
+
+// TestPutEventsBatchesBySpan verifies that events more than 24h apart are
+// never sent in the same batch.
+func TestPutEventsBatchesBySpan(t *testing.T) {
+	day := (24 * time.Hour).Milliseconds()
+	events := []types.InputLogEvent{
+		inputEvent("a", 0),
+		inputEvent("b", day/2),
+		inputEvent("c", day),
+	}
+	batches := sendAll(t, &mockPutClient{}, events)
+	if len(batches) != 2 {
+		t.Fatalf("expected 2 batches, got %d", len(batches))
+	}
+}
+
+// TestSendBatchSortsEvents verifies that events are sent in chronological
+// order, which PutLogEvents requires.
+func TestSendBatchSortsEvents(t *testing.T) {
+	client := &mockPutClient{}
+	events := []types.InputLogEvent{inputEvent("b", 2), inputEvent("a", 1), inputEvent("c", 3)}
+	if err := sendBatch(context.Background(), client, "g", "s", events); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := ""
+	for _, e := range client.batches[0] {
+		got += *e.Message
+	}
+	if got != "abc" {
+		t.Fatalf("expected events in order abc, got %s", got)
+	}
+}
+
+// TestSendBatchRetriesOnThrottle verifies that throttled uploads are retried
+// and eventually succeed.
+func TestSendBatchRetriesOnThrottle(t *testing.T) {
+	oldDelay := putRetryDelay
+	putRetryDelay = 0
+	defer func() { putRetryDelay = oldDelay }()
+
+	client := &mockPutClient{throttles: 2}
+	if err := sendBatch(context.Background(), client, "g", "s", []types.InputLogEvent{inputEvent("a", 1)}); err != nil {
+		t.Fatalf("expected success after retries, got: %v", err)
+	}
+	if client.calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", client.calls)
+	}
+}
+
+// TestSendBatchGivesUpAfterMaxRetries verifies that persistent throttling
+// surfaces an error instead of retrying forever.
+func TestSendBatchGivesUpAfterMaxRetries(t *testing.T) {
+	oldDelay := putRetryDelay
+	putRetryDelay = 0
+	defer func() { putRetryDelay = oldDelay }()
+
+	client := &mockPutClient{throttles: 100}
+	if err := sendBatch(context.Background(), client, "g", "s", []types.InputLogEvent{inputEvent("a", 1)}); err == nil {
+		t.Fatal("expected error after exhausting retries")
+	}
+	if client.calls != maxPutRetries+1 {
+		t.Fatalf("expected %d calls, got %d", maxPutRetries+1, client.calls)
+	}
+}
+
+// TestPutEventsFlushInterval verifies that a partial batch is uploaded after
+// the flush interval even though the input channel is still open.
+func TestPutEventsFlushInterval(t *testing.T) {
+	client := &mockPutClient{}
+	ch := make(chan types.InputLogEvent)
+	done := make(chan error)
+	go func() {
+		done <- putEvents(context.Background(), client, "g", "s", ch, 10*time.Millisecond)
+	}()
+	ch <- inputEvent("a", 1)
+	time.Sleep(50 * time.Millisecond)
+	ch <- inputEvent("b", 2)
+	close(ch)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.batches) != 2 {
+		t.Fatalf("expected 2 batches, got %d", len(client.batches))
+	}
+}
+
+// TestReportRejected verifies that each kind of rejection is reported.
+func TestReportRejected(t *testing.T) {
+	var buf bytes.Buffer
+	reportRejected(&buf, &types.RejectedLogEventsInfo{
+		TooOldLogEventEndIndex:   aws.Int32(2),
+		ExpiredLogEventEndIndex:  aws.Int32(1),
+		TooNewLogEventStartIndex: aws.Int32(5),
+	})
+	out := buf.String()
+	for _, want := range []string{"too old", "retention", "too new"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got %q", want, out)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Embedded Metric Format limits. See:
+// https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html
+const (
+	maxEMFMetrics     = 100 // metrics per document
+	maxEMFDimensions  = 30  // dimensions per dimension set
+	maxEMFNameLength  = 255
+	maxEMFValueLength = 1024
+	emfMetadataKey    = "_aws"
+)
+
+var emfUnits = []string{
+	"Seconds", "Microseconds", "Milliseconds",
+	"Bytes", "Kilobytes", "Megabytes", "Gigabytes", "Terabytes",
+	"Bits", "Kilobits", "Megabits", "Gigabits", "Terabits",
+	"Percent", "Count",
+	"Bytes/Second", "Kilobytes/Second", "Megabytes/Second", "Gigabytes/Second", "Terabytes/Second",
+	"Bits/Second", "Kilobits/Second", "Megabits/Second", "Gigabits/Second", "Terabits/Second",
+	"Count/Second", "None",
+}
+
+type emfMetric struct {
+	Name  string
+	Value float64
+	Unit  string
+}
+
+type emfDimension struct {
+	Key   string
+	Value string
+}
+
+// parseEMFMetric parses "name=value" or "name=value[Unit]".
+func parseEMFMetric(spec string) (emfMetric, error) {
+	name, rest, ok := strings.Cut(spec, "=")
+	if !ok || name == "" {
+		return emfMetric{}, fmt.Errorf("invalid metric %q: expected name=value[Unit]", spec)
+	}
+	m := emfMetric{Name: name}
+	if i := strings.Index(rest, "["); i >= 0 {
+		if !strings.HasSuffix(rest, "]") {
+			return emfMetric{}, fmt.Errorf("invalid metric %q: unterminated unit", spec)
+		}
+		m.Unit = rest[i+1 : len(rest)-1]
+		rest = rest[:i]
+	}
+	value, err := strconv.ParseFloat(rest, 64)
+	if err != nil {
+		return emfMetric{}, fmt.Errorf("invalid metric %q: value %q is not a number", spec, rest)
+	}
+	m.Value = value
+	return m, m.validate()
+}
+
+func (m emfMetric) validate() error {
+	if err := validateEMFName("metric name", m.Name); err != nil {
+		return err
+	}
+	if math.IsNaN(m.Value) || math.IsInf(m.Value, 0) {
+		return fmt.Errorf("metric %s: value must be a finite number", m.Name)
+	}
+	if m.Unit != "" && !slices.Contains(emfUnits, m.Unit) {
+		return fmt.Errorf("metric %s: unknown unit %q, must be one of %s", m.Name, m.Unit, strings.Join(emfUnits, ", "))
+	}
+	return nil
+}
+
+// parseEMFDimension parses "key=value".
+func parseEMFDimension(spec string) (emfDimension, error) {
+	key, value, ok := strings.Cut(spec, "=")
+	if !ok {
+		return emfDimension{}, fmt.Errorf("invalid dimension %q: expected key=value", spec)
+	}
+	if err := validateEMFName("dimension name", key); err != nil {
+		return emfDimension{}, err
+	}
+	if strings.HasPrefix(key, ":") {
+		return emfDimension{}, fmt.Errorf("dimension name %q must not start with ':'", key)
+	}
+	if value == "" || len(value) > maxEMFValueLength {
+		return emfDimension{}, fmt.Errorf("dimension %s: value must be 1-%d characters", key, maxEMFValueLength)
+	}
+	return emfDimension{Key: key, Value: value}, nil
+}
+
+func validateEMFName(kind, name string) error {
+	if name == "" || len(name) > maxEMFNameLength {
+		return fmt.Errorf("%s %q must be 1-%d characters", kind, name, maxEMFNameLength)
+	}
+	if name == emfMetadataKey {
+		return fmt.Errorf("%s %q is reserved", kind, name)
+	}
+	return nil
+}
+
+// emfDocument builds EMF JSON documents for one set of metrics. Dimension
+// sets are lists of dimension keys; with no sets given, all dimensions form
+// a single set. More than 100 metrics are spread over several documents,
+// each repeating the dimension values.
+type emfDocument struct {
+	Namespace     string
+	Dimensions    []emfDimension
+	DimensionSets [][]string
+}
+
+func (d emfDocument) validate() error {
+	if d.Namespace == "" || len(d.Namespace) > maxEMFNameLength {
+		return fmt.Errorf("namespace must be 1-%d characters", maxEMFNameLength)
+	}
+	if strings.HasPrefix(d.Namespace, "AWS/") {
+		return fmt.Errorf("namespace %q: the AWS/ prefix is reserved", d.Namespace)
+	}
+	keys := map[string]bool{}
+	for _, dim := range d.Dimensions {
+		if keys[dim.Key] {
+			return fmt.Errorf("dimension %s given more than once", dim.Key)
+		}
+		keys[dim.Key] = true
+	}
+	for _, set := range d.sets() {
+		if len(set) > maxEMFDimensions {
+			return fmt.Errorf("dimension set %v has more than %d dimensions", set, maxEMFDimensions)
+		}
+		for _, key := range set {
+			if !keys[key] {
+				return fmt.Errorf("dimension set %v refers to %s, which has no --dimension value", set, key)
+			}
+		}
+	}
+	return nil
+}
+
+func (d emfDocument) sets() [][]string {
+	if len(d.DimensionSets) > 0 {
+		return d.DimensionSets
+	}
+	set := make([]string, len(d.Dimensions))
+	for i, dim := range d.Dimensions {
+		set[i] = dim.Key
+	}
+	return [][]string{set}
+}
+
+// Render validates the metrics and returns one JSON document per group of
+// up to 100 metrics.
+func (d emfDocument) Render(metrics []emfMetric, ts time.Time) ([]string, error) {
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+	if len(metrics) == 0 {
+		return nil, fmt.Errorf("no metrics given")
+	}
+	seen := map[string]bool{}
+	for _, dim := range d.Dimensions {
+		seen[dim.Key] = true
+	}
+	for _, m := range metrics {
+		if err := m.validate(); err != nil {
+			return nil, err
+		}
+		if seen[m.Name] {
+			return nil, fmt.Errorf("metric %s collides with a dimension or another metric of the same name", m.Name)
+		}
+		seen[m.Name] = true
+	}
+
+	var docs []string
+	for chunk := range slices.Chunk(metrics, maxEMFMetrics) {
+		definitions := make([]map[string]string, len(chunk))
+		root := map[string]interface{}{}
+		for _, dim := range d.Dimensions {
+			root[dim.Key] = dim.Value
+		}
+		for i, m := range chunk {
+			definitions[i] = map[string]string{"Name": m.Name}
+			if m.Unit != "" {
+				definitions[i]["Unit"] = m.Unit
+			}
+			root[m.Name] = m.Value
+		}
+		root[emfMetadataKey] = map[string]interface{}{
+			"Timestamp": ts.UnixMilli(),
+			"CloudWatchMetrics": []map[string]interface{}{{
+				"Namespace":  d.Namespace,
+				"Dimensions": d.sets(),
+				"Metrics":    definitions,
+			}},
+		}
+		data, err := json.Marshal(root)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, string(data))
+	}
+	return docs, nil
+}
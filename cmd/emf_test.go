@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseEMFMetric verifies metric specs with and without units, and that
+// malformed values and unknown units are rejected.
+func TestParseEMFMetric(t *testing.T) {
+	m, err := parseEMFMetric("latency=12.5[Milliseconds]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "latency" || m.Value != 12.5 || m.Unit != "Milliseconds" {
+		t.Fatalf("unexpected metric %+v", m)
+	}
+	if m, err = parseEMFMetric("errors=3"); err != nil || m.Unit != "" {
+		t.Fatalf("unexpected metric %+v, err %v", m, err)
+	}
+	for _, spec := range []string{"latency", "=1", "x=abc", "x=NaN", "x=1[Parsecs]", "x=1[Count", "_aws=1"} {
+		if _, err := parseEMFMetric(spec); err == nil {
+			t.Errorf("expected error for %q", spec)
+		}
+	}
+}
+
+// TestEMFDocumentValidation verifies that bad namespaces, dimensions and
+// dimension sets are rejected before anything is rendered.
+func TestEMFDocumentValidation(t *testing.T) {
+	metrics := []emfMetric{{Name: "count", Value: 1}}
+	service := emfDimension{Key: "Service", Value: "api"}
+	cases := map[string]emfDocument{
+		"missing namespace":   {},
+		"reserved namespace":  {Namespace: "AWS/Lambda"},
+		"duplicate dimension": {Namespace: "App", Dimensions: []emfDimension{service, service}},
+		"unknown set key":     {Namespace: "App", Dimensions: []emfDimension{service}, DimensionSets: [][]string{{"Region"}}},
+		"metric collision":    {Namespace: "App", Dimensions: []emfDimension{{Key: "count", Value: "x"}}},
+	}
+	for name, doc := range cases {
+		if _, err := doc.Render(metrics, time.Now()); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := parseEMFDimension(":Service=api"); err == nil {
+		t.Error("expected error for dimension starting with ':'")
+	}
+	if _, err := parseEMFDimension("Service="); err == nil {
+		t.Error("expected error for empty dimension value")
+	}
+}
+
+// TestEMFRender verifies the _aws metadata block and that more than 100
+// metrics are spread over several documents.
+func TestEMFRender(t *testing.T) {
+	doc := emfDocument{
+		Namespace:     "App",
+		Dimensions:    []emfDimension{{Key: "Service", Value: "api"}, {Key: "Region", Value: "us-west-2"}},
+		DimensionSets: [][]string{{"Service"}, {"Service", "Region"}},
+	}
+	metrics := make([]emfMetric, 150)
+	for i := range metrics {
+		metrics[i] = emfMetric{Name: "m" + strings.Repeat("x", i), Value: float64(i), Unit: "Count"}
+	}
+	ts := time.UnixMilli(1700000000000)
+	docs, err := doc.Render(metrics, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(docs))
+	}
+
+	var parsed struct {
+		Service string `json:"Service"`
+		AWS     struct {
+			Timestamp         int64 `json:"Timestamp"`
+			CloudWatchMetrics []struct {
+				Namespace  string              `json:"Namespace"`
+				Dimensions [][]string          `json:"Dimensions"`
+				Metrics    []map[string]string `json:"Metrics"`
+			} `json:"CloudWatchMetrics"`
+		} `json:"_aws"`
+	}
+	if err := json.Unmarshal([]byte(docs[1]), &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Service != "api" || parsed.AWS.Timestamp != ts.UnixMilli() {
+		t.Fatalf("unexpected document %s", docs[1])
+	}
+	cwm := parsed.AWS.CloudWatchMetrics
+	if len(cwm) != 1 || cwm[0].Namespace != "App" || len(cwm[0].Dimensions) != 2 || len(cwm[0].Metrics) != 50 {
+		t.Fatalf("unexpected metadata %+v", cwm)
+	}
+	if cwm[0].Metrics[0]["Unit"] != "Count" {
+		t.Fatalf("expected unit in metric definition, got %v", cwm[0].Metrics[0])
+	}
+}
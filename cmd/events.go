@@ -34,6 +34,7 @@ var (
 	eventsPrefix       string
 	maxEvents          int
 	maxEmptyPages      = 5 // consecutive empty pages before stopping in non-follow mode
+	eventsConcurrency  int
 )
 
 func init() {
@@ -44,6 +45,8 @@ func init() {
 	eventsCmd.PersistentFlags().StringVarP(&stream, "stream", "s", "", "Log stream name")
 	eventsCmd.PersistentFlags().StringVar(&eventsPrefix, "follow-prefix", "", "Follow all streams matching prefix (requires --group and -f)")
 	eventsCmd.PersistentFlags().IntVar(&maxEvents, "limit", 0, "Maximum number of events to fetch (0 = unlimited)")
+	eventsCmd.PersistentFlags().IntVar(&eventsConcurrency, "concurrency", 8, "Number of streams read at once (with -f, the number of streams followed)")
+	eventsCmd.PersistentFlags().StringArrayVar(&tagSelectors, "tag", nil, "Read every stream of the log groups with this tag, as key=value or just key (repeatable)")
 	rootCmd.AddCommand(eventsCmd)
 }
 
@@ -95,54 +98,59 @@ func writeEvents(events <-chan Event) {
 // In non-follow mode, bails out after maxEmptyPages consecutive empty responses
 // with changing tokens to avoid infinite loops on sparse streams.
 func requestEvents(client interfaces.CloudWatchLogsClient, groupName, streamName string, outputChan chan Event, style *lipgloss.Style, limit int) error {
-	var nextToken *string
-	var interval time.Duration
-	totalSent := 0
-	emptyPages := 0
-	for {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		input := &cloudwatchlogs.GetLogEventsInput{
-			LogGroupName:  &groupName,
-			LogStreamName: &streamName,
-			StartFromHead: aws.Bool(!follow), // in follow mode, we want the latest events
-			NextToken:     nextToken,
-			Limit:         aws.Int32(10000), // 10,000 is max allowed by AWS
-		}
-		output, err := client.GetLogEvents(ctx, input)
-		cancel()
+	input := &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  &groupName,
+		LogStreamName: &streamName,
+		StartFromHead: aws.Bool(!follow), // in follow mode, we want the latest events
+		Limit:         aws.Int32(10000),  // 10,000 is max allowed by AWS
+	}
+	opts := fetch.EventOptions{
+		PageOptions:   fetch.PageOptions{Limit: limit, PageTimeout: 30 * time.Second},
+		Follow:        follow,
+		MinInterval:   minPollingInterval,
+		MaxInterval:   maxPollingInterval,
+		MaxEmptyPages: maxEmptyPages,
+	}
+	for event, err := range fetch.LogEvents(context.Background(), client, input, opts) {
 		if err != nil {
 			return err
 		}
+		outputChan <- Event{event, style}
+	}
+	return nil
+}
 
-		for _, event := range output.Events {
-			outputChan <- Event{event, style}
-			totalSent++
-			if limit > 0 && totalSent >= limit {
-				return nil
-			}
-		}
-
-		if len(output.Events) > 0 {
-			interval = minPollingInterval
-			emptyPages = 0
-		} else {
-			interval = min(maxPollingInterval, interval*2)
-			emptyPages++
-		}
+// streamJob is one stream for readStreams, with the style its events are
+// rendered in.
+type streamJob struct {
+	group, stream string
+	style         *lipgloss.Style
+}
 
-		if nextToken != nil && *nextToken == *output.NextForwardToken {
-			if follow {
-				time.Sleep(interval)
-			} else {
-				break
+// readStreams reads the streams sent on jobs with concurrency workers, so
+// expanding a large group doesn't start thousands of GetLogEvents calls at
+// once. Streams that fail are reported on stderr; the number of them is
+// returned once jobs is closed and every stream is done.
+func readStreams(client interfaces.CloudWatchLogsClient, jobs <-chan streamJob, concurrency int, out chan Event) int {
+	var mu sync.Mutex
+	failed := 0
+	var wg sync.WaitGroup
+	for range max(concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if err := requestEvents(client, job.group, job.stream, out, job.style, maxEvents); err != nil {
+					log.Printf("failed to read %s/%s: %v", job.group, job.stream, err)
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
 			}
-		} else if len(output.Events) == 0 && !follow && emptyPages >= maxEmptyPages {
-			// Token keeps changing but no events — stop to avoid infinite loop on sparse streams
-			break
-		}
-		nextToken = output.NextForwardToken
+		}()
 	}
-	return nil
+	wg.Wait()
+	return failed
 }
 
 var eventsCmd = &cobra.Command{
@@ -150,11 +158,23 @@ var eventsCmd = &cobra.Command{
 	Short: "list events for log stream(s)",
 	Long: `Lists events for a log stream. Provide a stream ARN or use --group and --stream flags.
 Use --group with --follow-prefix and -f to follow all streams matching a prefix.
+Without arguments, streams are read from stdin as ARNs, JSON objects (e.g.
+from cwl streams --json), --long tables or group names, which expand into
+all their streams.
 Examples:
   cwl events arn:aws:logs:us-west-2:123456789012:log-group:/my/log/group:log-stream:my-stream
   cwl events --group /my/log/group --stream my-stream
-  cwl events -f --group /my/log/group --follow-prefix "2025/04/"`,
+  cwl events -f --group /my/log/group --follow-prefix "2025/04/"
+  cwl streams /my/log/group --json | jq -c 'select(.StoredBytes > 0)' | cwl events
+  echo /my/log/group | cwl events
+  cwl events --tag team=payments --limit 100`,
 	Args: func(cmd *cobra.Command, args []string) error {
+		if eventsConcurrency < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+		if len(tagSelectors) > 0 && (group != "" || stream != "" || eventsPrefix != "" || len(args) != 0) {
+			return fmt.Errorf("--tag cannot be used with --group, --stream, --follow-prefix or ARN arguments")
+		}
 		if eventsPrefix != "" {
 			if group == "" {
 				return fmt.Errorf("--follow-prefix requires --group")
@@ -185,7 +205,14 @@ Examples:
 
 		var readFrom io.Reader
 
-		if group != "" && stream != "" {
+		if len(tagSelectors) > 0 {
+			// every stream of the tagged groups, as if their names were piped in
+			names, err := selectGroupsByTag(context.TODO(), client)
+			if err != nil {
+				log.Fatal(err)
+			}
+			readFrom = strings.NewReader(strings.Join(names, "\n"))
+		} else if group != "" && stream != "" {
 			// passed in both a group and a stream
 			virtualArn := arn.CreateVirtualArn(group, stream)
 			readFrom = strings.NewReader(virtualArn)
@@ -224,68 +251,93 @@ Examples:
 			seen := map[string]struct{}{}
 			streamIdx := 0
 			for {
-				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-				var nextToken *string
-				for {
-					output, err := client.DescribeLogStreams(ctx, &cloudwatchlogs.DescribeLogStreamsInput{
-						LogGroupName:        &group,
-						LogStreamNamePrefix: &eventsPrefix,
-						OrderBy:             types.OrderByLogStreamName,
-						Limit:               aws.Int32(50),
-						NextToken:           nextToken,
-					})
+				input := &cloudwatchlogs.DescribeLogStreamsInput{
+					LogGroupName:        &group,
+					LogStreamNamePrefix: &eventsPrefix,
+					OrderBy:             types.OrderByLogStreamName,
+					Limit:               aws.Int32(50),
+				}
+				for s, err := range fetch.LogStreams(context.Background(), client, input, fetch.PageOptions{PageTimeout: 30 * time.Second}) {
 					if err != nil {
 						log.Fatal(err)
 					}
-					for _, s := range output.LogStreams {
-						name := *s.LogStreamName
-						if _, ok := seen[name]; !ok {
-							seen[name] = struct{}{}
-							var style *lipgloss.Style
-							if streamIdx != 0 && len(styles) > 0 {
-								style = styles[streamIdx%len(styles)]
-							}
-							streamIdx++
-							wg.Add(1)
-							go func(sn string, st *lipgloss.Style) {
-								defer wg.Done()
-								requestEvents(client, group, sn, eventChannel, st, maxEvents)
-							}(name, style)
+					name := *s.LogStreamName
+					if _, ok := seen[name]; !ok {
+						seen[name] = struct{}{}
+						var style *lipgloss.Style
+						if streamIdx != 0 && len(styles) > 0 {
+							style = styles[streamIdx%len(styles)]
 						}
-					}
-					if output.NextToken != nil {
-						nextToken = output.NextToken
-					} else {
-						break
+						streamIdx++
+						wg.Add(1)
+						go func(sn string, st *lipgloss.Style) {
+							defer wg.Done()
+							if err := requestEvents(client, group, sn, eventChannel, st, maxEvents); err != nil {
+								log.Printf("failed to read %s/%s: %v", group, sn, err)
+							}
+						}(name, style)
 					}
 				}
-				cancel()
 				time.Sleep(10 * time.Second)
 			}
 		}
 
+		jobs := make(chan streamJob)
+		failedCh := make(chan int, 1)
+		go func() {
+			failedCh <- readStreams(client, jobs, eventsConcurrency, eventChannel)
+		}()
+
 		scanner := bufio.NewScanner(readFrom)
 		streamIdx := 0
-
-		// iterate over streams and request events for each
-		for scanner.Scan() {
-			streamArn := scanner.Text()
-			streamId := arn.ParseStreamArn(streamArn)
+		startStream := func(g, s string) {
 			var style *lipgloss.Style
 			if streamIdx != 0 && len(styles) > 0 {
 				style = styles[streamIdx%len(styles)]
 			}
-			wg.Add(1)
-			go func(g, s string, ech chan Event, st *lipgloss.Style) {
-				defer wg.Done()
-				requestEvents(client, g, s, ech, st, maxEvents)
-			}(streamId.GroupName, streamId.StreamName, eventChannel, style)
+			if follow && streamIdx == eventsConcurrency {
+				// followed streams never finish, so later ones would wait forever
+				log.Printf("following the first %d streams; raise --concurrency to follow more", eventsConcurrency)
+			}
+			jobs <- streamJob{group: g, stream: s, style: style}
 			streamIdx++
 		}
 
+		// iterate over input lines and request events for each stream. A
+		// line naming a whole group expands into all of its streams.
+		var parser arn.Parser
+		for scanner.Scan() {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			streamId, ok, err := parser.Parse(scanner.Text())
+			if err != nil {
+				log.Printf("skipping input: %v", err)
+				continue
+			}
+			if !ok {
+				continue // a --long table's header
+			}
+			if streamId.StreamName != "" {
+				startStream(streamId.GroupName, streamId.StreamName)
+				continue
+			}
+			err = scanStreams(client, streamId.GroupName, streamFilters{}, func(s types.LogStream) bool {
+				startStream(streamId.GroupName, *s.LogStreamName)
+				return true
+			})
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
 		// wait on everything to finish
-		wg.Wait()
+		close(jobs)
+		failed := <-failedCh
 		close(eventChannel)
 		processWg.Wait()
+		if failed > 0 {
+			log.Fatalf("%d of %d streams failed", failed, streamIdx)
+		}
 	},
 }
@@ -1,9 +1,17 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/derricw/cwl/arn"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 )
 
 func TestStreamArnToName(t *testing.T) {
@@ -60,6 +68,7 @@ func TestEventsArgsValidation(t *testing.T) {
 		group_      string
 		stream_     string
 		prefix_     string
+		tags        []string
 		args        []string
 		expectError bool
 		errorMsg    string
@@ -133,6 +142,18 @@ func TestEventsArgsValidation(t *testing.T) {
 			expectError: true,
 			errorMsg:    "--follow-prefix cannot be used with --stream or ARN arguments",
 		},
+		{
+			name:        "valid tag",
+			tags:        []string{"team=payments"},
+			expectError: false,
+		},
+		{
+			name:        "tag with group",
+			group_:      "/my/group",
+			tags:        []string{"team=payments"},
+			expectError: true,
+			errorMsg:    "--tag cannot be used with --group, --stream, --follow-prefix or ARN arguments",
+		},
 	}
 
 	for _, tt := range tests {
@@ -141,10 +162,12 @@ func TestEventsArgsValidation(t *testing.T) {
 			group = tt.group_
 			stream = tt.stream_
 			eventsPrefix = tt.prefix_
+			tagSelectors = tt.tags
 			defer func() {
 				group = ""
 				stream = ""
 				eventsPrefix = ""
+				tagSelectors = nil
 			}()
 
 			err := eventsCmd.Args(eventsCmd, tt.args)
@@ -162,3 +185,58 @@ func TestEventsArgsValidation(t *testing.T) {
 		})
 	}
 }
+
+// concurrentEventsClient serves one event per stream, fails the stream
+// named "bad", and records how many calls overlap.
+type concurrentEventsClient struct {
+	mockEventsClient
+	mu      sync.Mutex
+	active  int
+	maxSeen int
+}
+
+func (c *concurrentEventsClient) GetLogEvents(ctx context.Context, params *cloudwatchlogs.GetLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetLogEventsOutput, error) {
+	c.mu.Lock()
+	c.active++
+	c.maxSeen = max(c.maxSeen, c.active)
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.active--
+		c.mu.Unlock()
+	}()
+	time.Sleep(5 * time.Millisecond)
+	if *params.LogStreamName == "bad" {
+		return nil, errors.New("throttled")
+	}
+	return &cloudwatchlogs.GetLogEventsOutput{
+		Events: []types.OutputLogEvent{{Message: params.LogStreamName}},
+	}, nil
+}
+
+// TestReadStreamsBoundedAndCountsFailures verifies that streams are read
+// by at most concurrency workers and that failed streams are counted
+// rather than dropped.
+func TestReadStreamsBoundedAndCountsFailures(t *testing.T) {
+	client := &concurrentEventsClient{}
+	jobs := make(chan streamJob)
+	out := make(chan Event, 100)
+	go func() {
+		for i := range 20 {
+			jobs <- streamJob{group: "g", stream: fmt.Sprint(i)}
+		}
+		jobs <- streamJob{group: "g", stream: "bad"}
+		close(jobs)
+	}()
+
+	failed := readStreams(client, jobs, 3, out)
+	if failed != 1 {
+		t.Errorf("failed = %d, want 1", failed)
+	}
+	if len(out) != 20 {
+		t.Errorf("got %d events, want 20", len(out))
+	}
+	if client.maxSeen > 3 {
+		t.Errorf("%d calls overlapped, want at most 3", client.maxSeen)
+	}
+}
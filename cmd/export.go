@@ -0,0 +1,424 @@
+package cmd
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/derricw/cwl/fetch"
+	"github.com/derricw/cwl/interfaces"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+const exportManifestName = "manifest.json"
+
+// lastEventSlack allows for LastEventTimestamp lagging behind: CloudWatch
+// updates it eventually, typically within an hour.
+const lastEventSlack = time.Hour
+
+var exportOut string
+var exportSince string
+var exportUntil string
+var exportFormat string
+var exportGzip bool
+var exportConcurrency int
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportOut, "out", "o", "", "Directory to write the export to (required)")
+	exportCmd.Flags().StringVar(&exportSince, "since", "", "Only export events after this time (e.g. 7d, or RFC3339)")
+	exportCmd.Flags().StringVar(&exportUntil, "until", "", "Only export events before this time (e.g. 1d, or RFC3339; default now)")
+	exportCmd.Flags().StringVar(&exportFormat, "format", "raw", "Output format: raw (one message per line) or jsonl")
+	exportCmd.Flags().BoolVarP(&exportGzip, "gzip", "z", false, "Compress each file with gzip")
+	exportCmd.Flags().IntVar(&exportConcurrency, "concurrency", 4, "Number of streams downloaded at once")
+	exportCmd.Flags().StringVarP(&prefix, "prefix", "P", "", "Only export streams with this name prefix")
+	exportCmd.Flags().StringVar(&activeSince, "active-since", "", "Only export streams with an event in this window (e.g. 2d)")
+	exportCmd.Flags().StringArrayVar(&tagSelectors, "tag", nil, "Export every log group with this tag, each into a subdirectory of --out (repeatable)")
+	exportCmd.MarkFlagRequired("out")
+	rootCmd.AddCommand(exportCmd)
+}
+
+// exportManifest records what an export covers and which streams are done.
+// It is rewritten after every finished stream, so an interrupted export can
+// pick up where it left off.
+type exportManifest struct {
+	path    string
+	mu      sync.Mutex
+	Group   string                     `json:"group"`
+	Start   int64                      `json:"start"` // ms, 0 = from the beginning
+	End     int64                      `json:"end"`   // ms
+	Format  string                     `json:"format"`
+	Gzip    bool                       `json:"gzip"`
+	Streams map[string]*exportedStream `json:"streams"`
+}
+
+// exportedStream describes one finished stream file.
+type exportedStream struct {
+	File       string `json:"file"`
+	Events     int    `json:"events"`
+	FirstEvent *int64 `json:"firstEvent,omitempty"`
+	LastEvent  *int64 `json:"lastEvent,omitempty"`
+}
+
+// loadExportManifest reads the manifest in dir, returning nil if there is
+// none yet.
+func loadExportManifest(dir string) (*exportManifest, error) {
+	path := filepath.Join(dir, exportManifestName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := &exportManifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	m.path = path
+	if m.Streams == nil {
+		m.Streams = map[string]*exportedStream{}
+	}
+	return m, nil
+}
+
+func (m *exportManifest) done(streamName string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.Streams[streamName]
+	return ok
+}
+
+func (m *exportManifest) record(streamName string, s *exportedStream) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Streams[streamName] = s
+	return m.save()
+}
+
+func (m *exportManifest) save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(m.path, data)
+}
+
+// extension is the file extension for the manifest's format.
+func (m *exportManifest) extension() string {
+	ext := ".log"
+	if m.Format == "jsonl" {
+		ext = ".jsonl"
+	}
+	if m.Gzip {
+		ext += ".gz"
+	}
+	return ext
+}
+
+// inWindow reports whether a stream may hold events in the export window,
+// judging by its first and last event times.
+func (m *exportManifest) inWindow(stream types.LogStream) bool {
+	if stream.FirstEventTimestamp != nil && *stream.FirstEventTimestamp > m.End {
+		return false
+	}
+	if m.Start > 0 && stream.LastEventTimestamp != nil &&
+		*stream.LastEventTimestamp+lastEventSlack.Milliseconds() < m.Start {
+		return false
+	}
+	return true
+}
+
+// streamFilePath maps a stream name to a relative file path. Slashes in the
+// name become directories, and segments that could escape the export
+// directory are replaced.
+func streamFilePath(streamName string) string {
+	segments := strings.Split(streamName, "/")
+	for i, s := range segments {
+		if s == "" || s == "." || s == ".." {
+			segments[i] = "_"
+		}
+	}
+	return filepath.Join(segments...)
+}
+
+// exportLine is one event in jsonl format.
+type exportLine struct {
+	Timestamp     int64  `json:"timestamp"`
+	IngestionTime int64  `json:"ingestionTime"`
+	Message       string `json:"message"`
+}
+
+// exportStream downloads the stream's events within the manifest's window to
+// its file. The file is written under a temporary name and renamed once
+// complete, so a partial download is never mistaken for a finished one.
+func exportStream(ctx context.Context, client interfaces.CloudWatchLogsClient, m *exportManifest, dir, streamName string) (*exportedStream, error) {
+	result := &exportedStream{File: streamFilePath(streamName) + m.extension()}
+	path := filepath.Join(dir, result.File)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path + ".partial")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if m.Gzip {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	input := &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String(m.Group),
+		LogStreamName: aws.String(streamName),
+		StartFromHead: aws.Bool(true),
+		EndTime:       aws.Int64(m.End),
+	}
+	if m.Start > 0 {
+		input.StartTime = aws.Int64(m.Start)
+	}
+	for e, err := range fetch.LogEvents(ctx, client, input, fetch.EventOptions{}) {
+		if err != nil {
+			return nil, err
+		}
+		if m.Format == "jsonl" {
+			err = enc.Encode(exportLine{
+				Timestamp:     aws.ToInt64(e.Timestamp),
+				IngestionTime: aws.ToInt64(e.IngestionTime),
+				Message:       aws.ToString(e.Message),
+			})
+		} else {
+			_, err = io.WriteString(w, strings.TrimRight(aws.ToString(e.Message), "\r\n")+"\n")
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Events++
+		if result.FirstEvent == nil || *e.Timestamp < *result.FirstEvent {
+			result.FirstEvent = aws.Int64(*e.Timestamp)
+		}
+		if result.LastEvent == nil || *e.Timestamp > *result.LastEvent {
+			result.LastEvent = aws.Int64(*e.Timestamp)
+		}
+	}
+
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return result, os.Rename(path+".partial", path)
+}
+
+// runExport downloads every listed stream not yet in the manifest, using up
+// to concurrency workers. Returns the number of streams and events exported
+// in this run.
+func runExport(ctx context.Context, client interfaces.CloudWatchLogsClient, m *exportManifest, dir string, streams []string, concurrency int) (int, int, error) {
+	work := make(chan string)
+	var mu sync.Mutex
+	var firstErr error
+	exported, events := 0, 0
+	var wg sync.WaitGroup
+	for range max(concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for streamName := range work {
+				result, err := exportStream(ctx, client, m, dir, streamName)
+				if err == nil {
+					err = m.record(streamName, result)
+				}
+				mu.Lock()
+				if err != nil {
+					log.Printf("failed to export %s: %v", streamName, err)
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					exported++
+					events += result.Events
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, streamName := range streams {
+		if !m.done(streamName) {
+			work <- streamName
+		}
+	}
+	close(work)
+	wg.Wait()
+	return exported, events, firstErr
+}
+
+// parseTimeArg parses an RFC3339 time, or an age (e.g. "7d") counted back
+// from now.
+func parseTimeArg(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	age, err := parseAge(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: expected RFC3339 or an age such as 7d", s)
+	}
+	return now.Add(-age), nil
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export [group]",
+	Short: "download a log group to local files",
+	Long: `Downloads the streams of a log group into a directory, one file per
+stream. Slashes in stream names become subdirectories.
+
+A manifest.json in the output directory records the time window, format and
+the event count and time bounds of every finished stream. Running the same
+export again skips streams that are already done, so an interrupted export
+can simply be restarted; the window stored in the manifest is reused so
+relative times like --since 7d don't drift between runs.
+
+With --tag, every matching group is exported into its own subdirectory of
+--out, named after the group, each with its own manifest.`,
+	Example: `
+    cwl export /aws/batch/job --since 7d --out ./dump
+    cwl export /my/app --prefix web/ --active-since 1d --format jsonl --gzip --out ./dump
+    cwl export --tag team=payments --since 1d --out ./dump
+  `,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if exportFormat != "raw" && exportFormat != "jsonl" {
+			return fmt.Errorf("invalid --format %q: must be raw or jsonl", exportFormat)
+		}
+		if exportConcurrency < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+		if len(tagSelectors) > 0 {
+			if len(args) > 0 {
+				return fmt.Errorf("pass either a group or --tag, not both")
+			}
+			return nil
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		now := time.Now()
+		filters := streamFilters{now: now}
+		var err error
+		if activeSince != "" {
+			if filters.activeSince, err = parseAge(activeSince); err != nil {
+				log.Fatal(err)
+			}
+		}
+		client, err := fetch.CreateClient(awsProfile)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if len(tagSelectors) == 0 {
+			if err := exportGroup(client, args[0], exportOut, filters); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		names, err := selectGroupsByTag(context.TODO(), client)
+		if err != nil {
+			log.Fatal(err)
+		}
+		failed := 0
+		for _, groupName := range names {
+			if err := exportGroup(client, groupName, exportGroupDir(exportOut, groupName), filters); err != nil {
+				log.Printf("%s: %v", groupName, err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			log.Fatalf("%d of %d groups failed; run the same command again to retry them", failed, len(names))
+		}
+	},
+}
+
+// exportGroupDir is where a group is exported when --tag selects several:
+// a subdirectory of out named like the group, as slashes in stream names
+// are handled.
+func exportGroupDir(out, groupName string) string {
+	return filepath.Join(out, strings.TrimPrefix(groupName, "/"))
+}
+
+// exportGroup exports one group into dir, resuming the export already
+// there if there is one.
+func exportGroup(client interfaces.CloudWatchLogsClient, groupName, dir string, filters streamFilters) error {
+	now := filters.now
+	m, err := loadExportManifest(dir)
+	if err != nil {
+		return err
+	}
+	if m != nil {
+		if m.Group != groupName || m.Format != exportFormat || m.Gzip != exportGzip {
+			return fmt.Errorf("%s holds an export of %s (format %s, gzip %v); use a new --out directory", dir, m.Group, m.Format, m.Gzip)
+		}
+		log.Printf("resuming export: %d streams already done", len(m.Streams))
+	} else {
+		m = &exportManifest{
+			path:    filepath.Join(dir, exportManifestName),
+			Group:   groupName,
+			End:     now.UnixMilli(),
+			Format:  exportFormat,
+			Gzip:    exportGzip,
+			Streams: map[string]*exportedStream{},
+		}
+		if exportSince != "" {
+			start, err := parseTimeArg(exportSince, now)
+			if err != nil {
+				return err
+			}
+			m.Start = start.UnixMilli()
+		}
+		if exportUntil != "" {
+			end, err := parseTimeArg(exportUntil, now)
+			if err != nil {
+				return err
+			}
+			m.End = end.UnixMilli()
+		}
+		if err := m.save(); err != nil {
+			return err
+		}
+	}
+
+	var streams []string
+	err = scanStreams(client, groupName, filters, func(stream types.LogStream) bool {
+		if m.inWindow(stream) {
+			streams = append(streams, aws.ToString(stream.LogStreamName))
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	exported, events, err := runExport(context.Background(), client, m, dir, streams, exportConcurrency)
+	log.Printf("exported %d streams (%d events) to %s", exported, events, dir)
+	if err != nil {
+		return fmt.Errorf("some streams failed; run the same command again to retry them")
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func outputEvent(msg string, ts int64) types.OutputLogEvent {
+	return types.OutputLogEvent{Message: aws.String(msg), Timestamp: aws.Int64(ts), IngestionTime: aws.Int64(ts)}
+}
+
+func testManifest(dir, format string, gz bool) *exportManifest {
+	return &exportManifest{
+		path:    filepath.Join(dir, exportManifestName),
+		Group:   "/my/app",
+		Start:   100,
+		End:     1000,
+		Format:  format,
+		Gzip:    gz,
+		Streams: map[string]*exportedStream{},
+	}
+}
+
+// TestExportWritesFilesAndManifest verifies one file per stream within the
+// time window, with slashes in stream names turned into directories, and
+// that the manifest records counts and bounds.
+func TestExportWritesFilesAndManifest(t *testing.T) {
+	dir := t.TempDir()
+	client := &mockPutClient{events: map[string][]types.OutputLogEvent{
+		"web/1": {outputEvent("too old", 50), outputEvent("a", 200), outputEvent("b\n", 300)},
+		"../x":  {outputEvent("c", 500)},
+	}}
+	m := testManifest(dir, "raw", false)
+	exported, events, err := runExport(context.Background(), client, m, dir, []string{"web/1", "../x"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported != 2 || events != 3 {
+		t.Fatalf("expected 2 streams and 3 events, got %d and %d", exported, events)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "web", "1.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a\nb\n" {
+		t.Fatalf("unexpected file content %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "_", "x.log")); err != nil {
+		t.Fatalf("expected .. to be replaced in the path: %v", err)
+	}
+
+	loaded, err := loadExportManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := loaded.Streams["web/1"]
+	if s == nil || s.Events != 2 || *s.FirstEvent != 200 || *s.LastEvent != 300 || s.File != filepath.Join("web", "1.log") {
+		t.Fatalf("unexpected manifest entry %+v", s)
+	}
+}
+
+// TestExportResumes verifies that streams already in the manifest are not
+// downloaded again.
+func TestExportResumes(t *testing.T) {
+	dir := t.TempDir()
+	client := &mockPutClient{events: map[string][]types.OutputLogEvent{
+		"done": {outputEvent("x", 200)},
+		"todo": {outputEvent("y", 200)},
+	}}
+	m := testManifest(dir, "raw", false)
+	m.Streams["done"] = &exportedStream{File: "done.log", Events: 1}
+
+	exported, _, err := runExport(context.Background(), client, m, dir, []string{"done", "todo"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported != 1 {
+		t.Fatalf("expected only the unfinished stream to be exported, got %d", exported)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "done.log")); !os.IsNotExist(err) {
+		t.Fatal("expected finished stream to be skipped")
+	}
+}
+
+func TestExportGzipJSONL(t *testing.T) {
+	dir := t.TempDir()
+	client := &mockPutClient{events: map[string][]types.OutputLogEvent{
+		"s": {outputEvent(`{"level":"info"}`, 200)},
+	}}
+	m := testManifest(dir, "jsonl", true)
+	if _, _, err := runExport(context.Background(), client, m, dir, []string{"s"}, 1); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filepath.Join(dir, "s.jsonl.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"timestamp":200,"ingestionTime":200,"message":"{\"level\":\"info\"}"}`
+	if strings.TrimSpace(string(data)) != want {
+		t.Fatalf("unexpected content %s", data)
+	}
+}
+
+func TestExportInWindow(t *testing.T) {
+	m := &exportManifest{Start: 10 * time.Hour.Milliseconds(), End: 20 * time.Hour.Milliseconds()}
+	old := types.LogStream{LastEventTimestamp: aws.Int64(5 * time.Hour.Milliseconds())}
+	lagging := types.LogStream{LastEventTimestamp: aws.Int64(9*time.Hour.Milliseconds() + 1)}
+	future := types.LogStream{FirstEventTimestamp: aws.Int64(21 * time.Hour.Milliseconds())}
+	if m.inWindow(old) || m.inWindow(future) {
+		t.Fatal("expected streams entirely outside the window to be skipped")
+	}
+	if !m.inWindow(lagging) {
+		t.Fatal("expected a stream within the last-event slack to be kept")
+	}
+}
+
+// TestExportTagArgs verifies that --tag replaces the group argument, and
+// that each tagged group gets its own directory under --out.
+func TestExportTagArgs(t *testing.T) {
+	tagSelectors = []string{"team=payments"}
+	defer func() { tagSelectors = nil }()
+	if err := exportCmd.Args(exportCmd, nil); err != nil {
+		t.Errorf("expected --tag without a group to be valid, got %v", err)
+	}
+	if err := exportCmd.Args(exportCmd, []string{"/my/app"}); err == nil {
+		t.Error("expected an error for a group together with --tag")
+	}
+	if got := exportGroupDir("dump", "/aws/lambda/api"); got != filepath.Join("dump", "aws", "lambda", "api") {
+		t.Errorf("exportGroupDir = %q", got)
+	}
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/derricw/cwl/fetch"
+	"github.com/derricw/cwl/interfaces"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+var findGroupFilter string
+var findPrefix bool
+var findConcurrency int
+var findRate float64
+var findQuiet bool
+
+func init() {
+	findStreamCmd.Flags().BoolVarP(&jsonOutput, "json", "", false, "Output full json")
+	findStreamCmd.Flags().StringVarP(&findGroupFilter, "filter", "f", "", "Server-side pattern selecting the log groups to search")
+	findStreamCmd.Flags().BoolVar(&findPrefix, "prefix", false, "Match stream names starting with the term (much faster than substring search)")
+	findStreamCmd.Flags().IntVar(&findConcurrency, "concurrency", 8, "Number of log groups searched at once")
+	findStreamCmd.Flags().Float64Var(&findRate, "rate", 10, "Maximum DescribeLogStreams calls per second (0 = unlimited)")
+	findStreamCmd.Flags().StringArrayVar(&tagSelectors, "tag", nil, "Only search the log groups with this tag, as key=value or just key (repeatable)")
+	findStreamCmd.Flags().BoolVarP(&findQuiet, "quiet", "q", false, "Don't report progress on stderr")
+	rootCmd.AddCommand(findStreamCmd)
+}
+
+// streamSearch finds streams by name across many log groups.
+type streamSearch struct {
+	client      interfaces.CloudWatchLogsClient
+	term        string
+	prefix      bool
+	concurrency int
+	// limiter paces DescribeLogStreams calls across all workers; nil means
+	// unlimited. The API allows 25 calls per second per account and region,
+	// shared with everything else calling it.
+	limiter <-chan time.Time
+}
+
+// run searches groups concurrently, calling found for each match and
+// progress after each group. found and progress are never called
+// concurrently. A group that can't be searched (e.g. deleted mid-scan) is
+// reported on stderr and skipped.
+func (s *streamSearch) run(ctx context.Context, groups []string, found func(types.LogStream), progress func(done, total, matches int)) {
+	var mu sync.Mutex
+	done, matches := 0, 0
+	work := make(chan string)
+	var wg sync.WaitGroup
+	for range max(s.concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for groupName := range work {
+				err := s.searchGroup(ctx, groupName, func(stream types.LogStream) {
+					mu.Lock()
+					defer mu.Unlock()
+					matches++
+					found(stream)
+				})
+				mu.Lock()
+				if err != nil {
+					log.Printf("skipping %s: %v", groupName, err)
+				}
+				done++
+				progress(done, len(groups), matches)
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, groupName := range groups {
+		work <- groupName
+	}
+	close(work)
+	wg.Wait()
+}
+
+// searchGroup lists the streams of one group that match. In prefix mode the
+// server filters by name; otherwise every stream has to be listed.
+func (s *streamSearch) searchGroup(ctx context.Context, groupName string, emit func(types.LogStream)) error {
+	input := &cloudwatchlogs.DescribeLogStreamsInput{LogGroupName: aws.String(groupName)}
+	if s.prefix {
+		input.LogStreamNamePrefix = aws.String(s.term)
+		input.OrderBy = types.OrderByLogStreamName
+	}
+	var client interfaces.CloudWatchLogsClient = s.client
+	if s.limiter != nil {
+		client = pacedClient{s.client, s.limiter}
+	}
+	for stream, err := range fetch.LogStreams(ctx, client, input, fetch.PageOptions{}) {
+		if err != nil {
+			return err
+		}
+		if s.prefix || strings.Contains(aws.ToString(stream.LogStreamName), s.term) {
+			emit(stream)
+		}
+	}
+	return nil
+}
+
+// pacedClient waits for a tick of limiter before each DescribeLogStreams
+// call.
+type pacedClient struct {
+	interfaces.CloudWatchLogsClient
+	limiter <-chan time.Time
+}
+
+func (c pacedClient) DescribeLogStreams(ctx context.Context, params *cloudwatchlogs.DescribeLogStreamsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+	select {
+	case <-c.limiter:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return c.CloudWatchLogsClient.DescribeLogStreams(ctx, params, optFns...)
+}
+
+// writeProgress reports search progress on one line of w, overwritten in
+// place when w is a terminal.
+func writeProgress(w io.Writer, terminal bool, done, total, matches int) {
+	if terminal {
+		fmt.Fprintf(w, "\rsearched %d/%d groups, %d matches", done, total, matches)
+		if done == total {
+			fmt.Fprintln(w)
+		}
+	} else if done == total || done%100 == 0 {
+		fmt.Fprintf(w, "searched %d/%d groups, %d matches\n", done, total, matches)
+	}
+}
+
+var findStreamCmd = &cobra.Command{
+	Use:   "find-stream [substring]",
+	Short: "find log streams by name across log groups",
+	Long: `Searches every log group (or those matching --filter or --tag) for streams whose
+name contains the given text, and prints their ARNs. Useful when you know a
+Batch job ID or a similar identifier but not which log group holds it.
+
+A substring search has to list every stream of every group, which can take
+a while on large accounts. With --prefix, the text must be the start of the
+stream name and CloudWatch filters server-side, which is much cheaper.
+Calls are spread over --concurrency workers and paced to --rate per second
+to stay clear of the DescribeLogStreams quota.`,
+	Example: `
+    cwl find-stream 4f1c2a3b-9d8e-4c7b-a6f5-1e2d3c4b5a69 | cwl events
+    cwl find-stream --prefix 2025/06/01 --filter lambda
+    cwl find-stream --tag team=payments 4f1c2a3b
+  `,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if findConcurrency < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+		if findRate < 0 {
+			return fmt.Errorf("--rate must not be negative")
+		}
+		if len(tagSelectors) > 0 && findGroupFilter != "" {
+			return fmt.Errorf("pass either --filter or --tag, not both")
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := fetch.CreateClient(awsProfile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var names []string
+		if len(tagSelectors) > 0 {
+			if names, err = selectGroupsByTag(context.Background(), client); err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			groups, err := fetch.FetchLogGroups(context.Background(), client, findGroupFilter)
+			if err != nil {
+				log.Fatal(err)
+			}
+			names = make([]string, len(groups))
+			for i, g := range groups {
+				names[i] = aws.ToString(g.LogGroupName)
+			}
+		}
+
+		search := &streamSearch{client: client, term: args[0], prefix: findPrefix, concurrency: findConcurrency}
+		if findRate > 0 {
+			ticker := time.NewTicker(time.Duration(float64(time.Second) / findRate))
+			defer ticker.Stop()
+			search.limiter = ticker.C
+		}
+
+		info, err := os.Stderr.Stat()
+		terminal := err == nil && info.Mode()&os.ModeCharDevice != 0
+		search.run(context.Background(), names,
+			func(stream types.LogStream) {
+				writeStream(os.Stdout, stream)
+			},
+			func(done, total, matches int) {
+				if !findQuiet {
+					writeProgress(os.Stderr, terminal, done, total, matches)
+				}
+			})
+	},
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// TestStreamSearchSubstring verifies that every group is searched across
+// pages, only streams containing the term are reported, and progress counts
+// reach the total.
+func TestStreamSearchSubstring(t *testing.T) {
+	client := &mockPutClient{streamPages: [][]types.LogStream{
+		{{LogStreamName: aws.String("job/default/abc123")}, {LogStreamName: aws.String("job/default/zzz")}},
+		{{LogStreamName: aws.String("other/abc123/x")}},
+	}}
+	search := &streamSearch{client: client, term: "abc123", concurrency: 3}
+	var found []string
+	lastDone, lastMatches := 0, 0
+	search.run(context.Background(), []string{"g1", "g2", "g3", "g4"},
+		func(stream types.LogStream) {
+			found = append(found, *stream.LogStreamName)
+		},
+		func(done, total, matches int) {
+			if total != 4 {
+				t.Errorf("expected total 4, got %d", total)
+			}
+			lastDone, lastMatches = done, matches
+		})
+
+	if len(found) != 8 || lastDone != 4 || lastMatches != 8 {
+		t.Fatalf("expected 8 matches over 4 groups, got %d (done %d, matches %d)", len(found), lastDone, lastMatches)
+	}
+	sort.Strings(found)
+	if found[0] != "job/default/abc123" || found[7] != "other/abc123/x" {
+		t.Fatalf("unexpected matches %v", found)
+	}
+	if client.streamCalls != 8 {
+		t.Fatalf("expected 2 pages per group, got %d calls", client.streamCalls)
+	}
+}
+
+// TestStreamSearchRateLimit verifies that every DescribeLogStreams call waits
+// for the limiter.
+func TestStreamSearchRateLimit(t *testing.T) {
+	client := &mockPutClient{streamPages: [][]types.LogStream{{{LogStreamName: aws.String("s")}}}}
+	limiter := make(chan time.Time, 3)
+	for range 3 {
+		limiter <- time.Now()
+	}
+	search := &streamSearch{client: client, term: "s", concurrency: 2, limiter: limiter}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	search.run(ctx, []string{"g1", "g2", "g3", "g4"}, func(types.LogStream) {}, func(int, int, int) {})
+	if client.streamCalls != 3 {
+		t.Fatalf("expected only 3 calls with 3 tokens, got %d", client.streamCalls)
+	}
+}
+
+func TestWriteProgress(t *testing.T) {
+	var out bytes.Buffer
+	for done := 1; done <= 150; done++ {
+		writeProgress(&out, false, done, 150, 2)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || lines[1] != "searched 150/150 groups, 2 matches" {
+		t.Fatalf("unexpected progress output %q", out.String())
+	}
+}
@@ -4,21 +4,45 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/derricw/cwl/fetch"
+	"github.com/derricw/cwl/interfaces"
+	"github.com/derricw/cwl/provider"
 	"github.com/spf13/cobra"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 )
 
 var groupFilter string
+var longOutput bool
+var groupSort string
+var minSize string
+var noRetention bool
+var classFilter string
+var groupRegions string
+var groupProfiles string
 
 func init() {
 	groupsCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output full json")
 	groupsCmd.PersistentFlags().StringVarP(&groupFilter, "filter", "f", "", "Server-side pattern to filter log groups")
+	groupsCmd.Flags().BoolVarP(&longOutput, "long", "l", false, "Output a table with size, retention, creation time, class, KMS and data protection")
+	groupsCmd.Flags().StringVar(&groupSort, "sort", "", "Sort groups by size, created or name (size and created are largest/newest first)")
+	groupsCmd.Flags().StringVar(&minSize, "min-size", "", "Only list groups storing at least this much (e.g. 500MB, 1.5GB)")
+	groupsCmd.Flags().BoolVar(&noRetention, "no-retention", false, "Only list groups that never expire events")
+	groupsCmd.Flags().StringArrayVar(&tagSelectors, "tag", nil, "Only list groups with this tag, as key=value or just key (repeatable, all must match)")
+	groupsCmd.Flags().StringVar(&classFilter, "class", "", "Only list groups of this log class (STANDARD, INFREQUENT_ACCESS or DELIVERY)")
+	groupsCmd.Flags().StringVar(&groupRegions, "regions", "", "Comma-separated regions to list, or \"all\"")
+	groupsCmd.Flags().StringVar(&groupProfiles, "profiles", "", "Comma-separated AWS profiles to list (default: --profile)")
 	rootCmd.AddCommand(groupsCmd)
 }
 
@@ -35,39 +59,266 @@ func writeGroup(group types.LogGroup) {
 	}
 }
 
+// locatedGroup is a log group annotated with where it was found, for
+// listings that span several profiles or regions. The LogGroup fields are
+// flattened into the same JSON object.
+type locatedGroup struct {
+	Profile string `json:"profile,omitempty"`
+	Region  string `json:"region,omitempty"`
+	Account string `json:"account,omitempty"`
+	types.LogGroup
+}
+
+func writeLocatedGroup(group locatedGroup) {
+	if jsonOutput {
+		jsonData, err := json.Marshal(group)
+		if err != nil {
+			fmt.Println("Error marshaling to JSON:", err)
+			return
+		}
+		fmt.Println(string(jsonData))
+	} else {
+		fmt.Printf("%s\t%s\t%s\t%s\n", group.Profile, group.Region, group.Account, *group.LogGroupName)
+	}
+}
+
+// groupFilters are the client-side filters applied after DescribeLogGroups.
+type groupFilters struct {
+	minBytes    int64
+	noRetention bool
+	class       types.LogGroupClass
+}
+
+func (f groupFilters) match(group types.LogGroup) bool {
+	if aws.ToInt64(group.StoredBytes) < f.minBytes {
+		return false
+	}
+	if f.noRetention && group.RetentionInDays != nil {
+		return false
+	}
+	if f.class != "" && group.LogGroupClass != f.class {
+		return false
+	}
+	return true
+}
+
+// sortGroups orders groups by "size" or "created" (both descending, since
+// cost reviews care about the biggest and newest first) or "name".
+func sortGroups(groups []locatedGroup, by string) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		switch by {
+		case "size":
+			return aws.ToInt64(a.StoredBytes) > aws.ToInt64(b.StoredBytes)
+		case "created":
+			return aws.ToInt64(a.CreationTime) > aws.ToInt64(b.CreationTime)
+		default:
+			return aws.ToString(a.LogGroupName) < aws.ToString(b.LogGroupName)
+		}
+	})
+}
+
+// writeGroupTable prints groups as aligned columns for --long. located
+// adds the profile, region and account columns.
+func writeGroupTable(w io.Writer, groups []locatedGroup, located bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if located {
+		fmt.Fprint(tw, "PROFILE\tREGION\tACCOUNT\t")
+	}
+	fmt.Fprintln(tw, "NAME\tSTORED\tRETENTION\tCREATED\tCLASS\tKMS\tDATA PROTECTION")
+	for _, group := range groups {
+		if located {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t", group.Profile, group.Region, group.Account)
+		}
+		retention := "never"
+		if group.RetentionInDays != nil {
+			retention = fmt.Sprintf("%dd", *group.RetentionInDays)
+		}
+		created := "-"
+		if group.CreationTime != nil {
+			created = time.UnixMilli(*group.CreationTime).UTC().Format("2006-01-02")
+		}
+		kms := "-"
+		if group.KmsKeyId != nil {
+			kms = "yes"
+		}
+		protection := string(group.DataProtectionStatus)
+		if protection == "" {
+			protection = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			aws.ToString(group.LogGroupName), provider.FormatBytes(aws.ToInt64(group.StoredBytes)),
+			retention, created, group.LogGroupClass, kms, protection)
+	}
+	tw.Flush()
+}
+
 var groupsCmd = &cobra.Command{
 	Use:   "groups",
 	Short: "list groups",
-	Long:  `Lists all available log groups`,
+	Long: `Lists all available log groups.
+
+--long prints a table with stored bytes, retention, creation time, log
+class, KMS encryption and data protection status. --sort, --min-size,
+--no-retention, --class and --tag work with every output mode, which makes
+cost reviews a one-liner.
+
+--regions and --profiles list several regions and profiles at once,
+querying them concurrently. Each group is then printed with its profile,
+region and account (tab-separated, or as extra fields with --json).`,
+	Example: `  # biggest groups that never expire events
+  cwl groups --long --sort size --no-retention --min-size 1GB
+
+  # groups owned by the payments team
+  cwl groups --tag team=payments
+
+  # which region did this Lambda@Edge log group land in?
+  cwl groups --regions all --filter my-edge-function`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		switch groupSort {
+		case "", "size", "created", "name":
+		default:
+			return fmt.Errorf("invalid --sort %q: must be size, created or name", groupSort)
+		}
+		if classFilter != "" && !slices.Contains(types.LogGroupClass("").Values(), types.LogGroupClass(classFilter)) {
+			return fmt.Errorf("invalid --class %q: must be one of %v", classFilter, types.LogGroupClass("").Values())
+		}
+		if longOutput && jsonOutput {
+			return fmt.Errorf("--long and --json are mutually exclusive")
+		}
+		return cobra.NoArgs(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
+		filters := groupFilters{noRetention: noRetention, class: types.LogGroupClass(classFilter)}
+		if minSize != "" {
+			var err error
+			if filters.minBytes, err = provider.ParseBytes(minSize); err != nil {
+				log.Fatal(err)
+			}
+		}
 
-		client, err := fetch.CreateClient(awsProfile)
+		selectors, err := parseTagSelectors(tagSelectors)
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		var nextToken *string
-
-		for {
-
-			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			input := &cloudwatchlogs.DescribeLogGroupsInput{NextToken: nextToken}
-			if groupFilter != "" {
-				input.LogGroupNamePattern = &groupFilter
-			}
-			output, err := client.DescribeLogGroups(ctx, input)
+		if groupRegions != "" || groupProfiles != "" {
+			targets, err := parseTargets(groupProfiles, groupRegions)
 			if err != nil {
 				log.Fatal(err)
 			}
-			cancel()
-			for _, group := range output.LogGroups {
-				writeGroup(group)
+			groups := listTargetGroups(context.Background(), targets, filters, selectors)
+			if groupSort != "" {
+				sortGroups(groups, groupSort)
+			}
+			if longOutput {
+				writeGroupTable(os.Stdout, groups, true)
+				return
 			}
-			if output.NextToken != nil {
-				nextToken = output.NextToken
+			for _, group := range groups {
+				writeLocatedGroup(group)
+			}
+			return
+		}
+
+		client, err := fetch.CreateClient(awsProfile)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// groups are only collected when they need sorting or a table;
+		// otherwise they're printed as each page arrives
+		collect := longOutput || groupSort != ""
+		var groups []locatedGroup
+		err = listGroups(context.Background(), client, filters, selectors, func(group types.LogGroup) {
+			if collect {
+				groups = append(groups, locatedGroup{LogGroup: group})
 			} else {
-				break
+				writeGroup(group)
 			}
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if groupSort != "" {
+			sortGroups(groups, groupSort)
+		}
+		if longOutput {
+			writeGroupTable(os.Stdout, groups, false)
+			return
+		}
+		for _, group := range groups {
+			writeGroup(group.LogGroup)
 		}
 	},
 }
+
+// listGroups pages through DescribeLogGroups and calls emit for every group
+// passing the filters.
+func listGroups(ctx context.Context, client interfaces.CloudWatchLogsClient, filters groupFilters, selectors []tagSelector, emit func(types.LogGroup)) error {
+	tags := newTagCache(client)
+	input := &cloudwatchlogs.DescribeLogGroupsInput{}
+	if groupFilter != "" {
+		input.LogGroupNamePattern = &groupFilter
+	}
+	for groups, err := range fetch.LogGroupPages(ctx, client, input, fetch.PageOptions{PageTimeout: 30 * time.Second}) {
+		if err != nil {
+			return err
+		}
+		var page []types.LogGroup
+		for _, group := range groups {
+			if filters.match(group) {
+				page = append(page, group)
+			}
+		}
+		// tags are looked up last, and only for groups that passed the
+		// cheaper filters
+		page, err = tags.filter(ctx, page, selectors)
+		if err != nil {
+			return err
+		}
+		for _, group := range page {
+			emit(group)
+		}
+	}
+	return nil
+}
+
+// listTargetGroups lists groups from every target concurrently. A target
+// that fails (e.g. a disabled opt-in region) is reported and skipped so the
+// rest of the listing still comes through. Results keep the target order.
+func listTargetGroups(ctx context.Context, targets []awsTarget, filters groupFilters, selectors []tagSelector) []locatedGroup {
+	results := make([][]locatedGroup, len(targets))
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			target := targets[i]
+			profile := target.profile
+			if profile == "" {
+				profile = "default"
+			}
+			client, err := target.client()
+			if err != nil {
+				log.Printf("skipping profile %s region %s: %v", profile, target.region, err)
+				return
+			}
+			err = listGroups(ctx, client, filters, selectors, func(group types.LogGroup) {
+				results[i] = append(results[i], locatedGroup{
+					Profile:  profile,
+					Region:   target.region,
+					Account:  accountFromArn(groupArn(group)),
+					LogGroup: group,
+				})
+			})
+			if err != nil {
+				log.Printf("skipping profile %s region %s: %v", profile, target.region, err)
+				results[i] = nil
+			}
+		}()
+	}
+	wg.Wait()
+	return slices.Concat(results...)
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/derricw/cwl/fetch"
+	"github.com/derricw/cwl/interfaces"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+)
+
+var retentionDays int
+var kmsKeyID string
+var logClass string
+var assumeYes bool
+var setTags []string
+var removeTags []string
+
+func init() {
+	addGroupOptionFlags(groupsCreateCmd)
+	groupsDeleteCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Skip the confirmation prompt")
+	groupsSetRetentionCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Skip the confirmation prompt")
+	groupsCmd.AddCommand(groupsCreateCmd)
+	groupsCmd.AddCommand(groupsDeleteCmd)
+	groupsTagsCmd.Flags().StringArrayVar(&setTags, "set", nil, "Add or update a tag, as key=value (repeatable)")
+	groupsTagsCmd.Flags().StringArrayVar(&removeTags, "remove", nil, "Remove the tag with this key (repeatable)")
+	groupsCmd.AddCommand(groupsSetRetentionCmd)
+	groupsCmd.AddCommand(groupsTagsCmd)
+}
+
+// addGroupOptionFlags registers the flags shared by every command that can
+// create a log group.
+func addGroupOptionFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&retentionDays, "retention-days", 0, "Retention for a new log group in days (0 = never expire)")
+	cmd.Flags().StringVar(&kmsKeyID, "kms-key-id", "", "KMS key ARN used to encrypt a new log group")
+	cmd.Flags().StringVar(&logClass, "log-class", "", "Log class for a new log group (STANDARD or INFREQUENT_ACCESS)")
+}
+
+func groupOptionsFromFlags() groupOptions {
+	return groupOptions{
+		retentionDays: int32(retentionDays),
+		kmsKeyID:      kmsKeyID,
+		logClass:      logClass,
+	}
+}
+
+var groupsCreateCmd = &cobra.Command{
+	Use:   "create [group]",
+	Short: "create a log group",
+	Long:  `Creates a log group, optionally with a retention policy, KMS key and log class.`,
+	Example: `
+    cwl groups create /my/app --retention-days 30 --log-class INFREQUENT_ACCESS
+  `,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := groupOptionsFromFlags()
+		if err := opts.validate(); err != nil {
+			log.Fatal(err)
+		}
+		client, err := fetch.CreateClient(awsProfile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := createLogGroup(context.TODO(), client, args[0], opts); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var groupsDeleteCmd = &cobra.Command{
+	Use:   "delete [group]...",
+	Short: "delete log groups",
+	Long:  `Deletes one or more log groups and all of their events. Asks for confirmation unless --yes is given.`,
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := fetch.CreateClient(awsProfile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		answers := bufio.NewReader(os.Stdin)
+		for _, groupName := range args {
+			prompt := fmt.Sprintf("Delete log group %s and all of its events?", groupName)
+			if !assumeYes && !confirm(answers, os.Stderr, prompt) {
+				log.Printf("Skipping %s", groupName)
+				continue
+			}
+			_, err := client.DeleteLogGroup(context.TODO(), &cloudwatchlogs.DeleteLogGroupInput{
+				LogGroupName: aws.String(groupName),
+			})
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+	},
+}
+
+var groupsSetRetentionCmd = &cobra.Command{
+	Use:   "set-retention [group] [days|never]",
+	Short: "set the retention policy of a log group",
+	Long: `Sets how long events in a log group are kept. Shortening retention deletes
+older events, so that asks for confirmation unless --yes is given. Keeping
+events longer, or forever with "never", doesn't ask.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		groupName := args[0]
+		days, err := parseRetention(args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+		client, err := fetch.CreateClient(awsProfile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		group, err := describeLogGroup(context.TODO(), client, groupName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if group == nil {
+			log.Fatalf("log group %s does not exist", groupName)
+		}
+		if shortensRetention(group.RetentionInDays, days) {
+			prompt := fmt.Sprintf("Set retention of %s to %d days? Older events will be deleted.", groupName, days)
+			if !assumeYes && !confirm(bufio.NewReader(os.Stdin), os.Stderr, prompt) {
+				return
+			}
+		}
+		if days == 0 {
+			_, err = client.DeleteRetentionPolicy(context.TODO(), &cloudwatchlogs.DeleteRetentionPolicyInput{
+				LogGroupName: aws.String(groupName),
+			})
+		} else {
+			_, err = client.PutRetentionPolicy(context.TODO(), &cloudwatchlogs.PutRetentionPolicyInput{
+				LogGroupName:    aws.String(groupName),
+				RetentionInDays: aws.Int32(days),
+			})
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+var groupsTagsCmd = &cobra.Command{
+	Use:   "tags [group]",
+	Short: "view or edit the tags of a log group",
+	Long: `Prints the tags of a log group as key=value lines (or a JSON object with
+--json). --set and --remove edit the tags first, and the result is printed.`,
+	Example: `
+    cwl groups tags /my/app
+    cwl groups tags /my/app --set team=payments --set service=checkout --remove owner
+  `,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		tags, err := parseTags(setTags)
+		if err != nil {
+			log.Fatal(err)
+		}
+		client, err := fetch.CreateClient(awsProfile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		current, err := editGroupTags(context.TODO(), client, args[0], tags, removeTags)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := writeTags(os.Stdout, current); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// editGroupTags applies tag changes to a log group and returns its tags
+// afterwards.
+func editGroupTags(ctx context.Context, client interfaces.CloudWatchLogsClient, groupName string, set map[string]string, remove []string) (map[string]string, error) {
+	group, err := describeLogGroup(ctx, client, groupName)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, fmt.Errorf("log group %s does not exist", groupName)
+	}
+	arn := aws.String(groupArn(*group))
+	if len(set) > 0 {
+		if _, err := client.TagResource(ctx, &cloudwatchlogs.TagResourceInput{ResourceArn: arn, Tags: set}); err != nil {
+			return nil, err
+		}
+	}
+	if len(remove) > 0 {
+		if _, err := client.UntagResource(ctx, &cloudwatchlogs.UntagResourceInput{ResourceArn: arn, TagKeys: remove}); err != nil {
+			return nil, err
+		}
+	}
+	return newTagCache(client).get(ctx, *arn)
+}
+
+func writeTags(w io.Writer, tags map[string]string) error {
+	if jsonOutput {
+		if tags == nil {
+			tags = map[string]string{}
+		}
+		data, err := json.Marshal(tags)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", key, tags[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
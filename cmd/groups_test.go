@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/derricw/cwl/interfaces"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// TestGroupFiltersAndSort verifies the client-side filters and that size
+// sorting puts the largest group first.
+func TestGroupFiltersAndSort(t *testing.T) {
+	groups := []locatedGroup{
+		{LogGroup: types.LogGroup{LogGroupName: aws.String("small"), StoredBytes: aws.Int64(10), RetentionInDays: aws.Int32(7)}},
+		{LogGroup: types.LogGroup{LogGroupName: aws.String("big"), StoredBytes: aws.Int64(5000), LogGroupClass: types.LogGroupClassInfrequentAccess}},
+		{LogGroup: types.LogGroup{LogGroupName: aws.String("medium"), StoredBytes: aws.Int64(1000), LogGroupClass: types.LogGroupClassStandard}},
+	}
+
+	filters := groupFilters{minBytes: 500, noRetention: true}
+	var kept []string
+	for _, g := range groups {
+		if filters.match(g.LogGroup) {
+			kept = append(kept, *g.LogGroupName)
+		}
+	}
+	if strings.Join(kept, ",") != "big,medium" {
+		t.Fatalf("unexpected filtered groups %v", kept)
+	}
+	if (groupFilters{class: types.LogGroupClassInfrequentAccess}).match(groups[2].LogGroup) {
+		t.Fatal("expected STANDARD group to be filtered out by --class INFREQUENT_ACCESS")
+	}
+
+	sortGroups(groups, "size")
+	if *groups[0].LogGroupName != "big" || *groups[2].LogGroupName != "small" {
+		t.Fatalf("unexpected order %v", []string{*groups[0].LogGroupName, *groups[1].LogGroupName, *groups[2].LogGroupName})
+	}
+}
+
+func TestWriteGroupTable(t *testing.T) {
+	var out bytes.Buffer
+	writeGroupTable(&out, []locatedGroup{{LogGroup: types.LogGroup{
+		LogGroupName:         aws.String("/my/app"),
+		StoredBytes:          aws.Int64(3 * 1024 * 1024),
+		CreationTime:         aws.Int64(1700000000000),
+		LogGroupClass:        types.LogGroupClassStandard,
+		KmsKeyId:             aws.String("arn:aws:kms:us-west-2:123:key/abc"),
+		DataProtectionStatus: types.DataProtectionStatusActivated,
+	}}}, false)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected header and one row, got %q", out.String())
+	}
+	fields := strings.Fields(lines[1])
+	want := []string{"/my/app", "3.0MB", "never", "2023-11-14", "STANDARD", "yes", "ACTIVATED"}
+	if strings.Join(fields, " ") != strings.Join(want, " ") {
+		t.Fatalf("unexpected row %v", fields)
+	}
+}
+
+// TestListTargetGroups verifies that every profile/region pair is listed,
+// results are annotated with where they came from, and a failing target is
+// skipped without losing the others.
+func TestListTargetGroups(t *testing.T) {
+	clients := map[string]*mockPutClient{
+		"us-east-1": {groupPages: [][]types.LogGroup{{taggedGroup("/aws/lambda/us-east-1.edge")}}},
+		"eu-west-1": {groupPages: [][]types.LogGroup{{taggedGroup("/aws/lambda/us-east-1.edge")}, {taggedGroup("/other")}}},
+	}
+	defer func(orig func(string, string) (interfaces.CloudWatchLogsClient, string, error)) {
+		newRegionalClient = orig
+	}(newRegionalClient)
+	newRegionalClient = func(profile, region string) (interfaces.CloudWatchLogsClient, string, error) {
+		client, ok := clients[region]
+		if !ok {
+			return nil, "", fmt.Errorf("region %s not enabled", region)
+		}
+		return client, region, nil
+	}
+
+	targets, err := parseTargets("dev", "us-east-1,ap-east-1,eu-west-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := listTargetGroups(context.Background(), targets, groupFilters{}, nil)
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(groups))
+	}
+	first, last := groups[0], groups[2]
+	if first.Profile != "dev" || first.Region != "us-east-1" || first.Account != "123" {
+		t.Fatalf("unexpected annotation %+v", first)
+	}
+	if last.Region != "eu-west-1" || *last.LogGroupName != "/other" {
+		t.Fatalf("expected results in target order, got %+v", last)
+	}
+
+	data, err := json.Marshal(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"region":"us-east-1"`) || !strings.Contains(string(data), `"LogGroupName":"/aws/lambda/us-east-1.edge"`) {
+		t.Fatalf("expected annotation and group fields in one object, got %s", data)
+	}
+}
+
+func TestParseTargets(t *testing.T) {
+	targets, err := parseTargets("a,b", "all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2*len(allRegions) {
+		t.Fatalf("expected every region for both profiles, got %d targets", len(targets))
+	}
+	if _, err := parseTargets("", "all,us-east-1"); err == nil {
+		t.Fatal("expected error combining all with other regions")
+	}
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/derricw/cwl/fetch"
+	"github.com/derricw/cwl/interfaces"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// validRetentionDays are the only values PutRetentionPolicy accepts.
+var validRetentionDays = []int32{1, 3, 5, 7, 14, 30, 60, 90, 120, 150, 180, 365, 400, 545, 731, 1096, 1827, 2192, 2557, 2922, 3288, 3653}
+
+// groupOptions configures log groups created by cwl.
+type groupOptions struct {
+	retentionDays int32 // 0 = never expire
+	kmsKeyID      string
+	logClass      string
+}
+
+func (o groupOptions) validate() error {
+	if o.retentionDays != 0 && !slices.Contains(validRetentionDays, o.retentionDays) {
+		return fmt.Errorf("invalid retention %d days, must be one of %v", o.retentionDays, validRetentionDays)
+	}
+	if o.logClass != "" && !slices.Contains(types.LogGroupClass("").Values(), types.LogGroupClass(o.logClass)) {
+		return fmt.Errorf("invalid log class %q, must be one of %v", o.logClass, types.LogGroupClass("").Values())
+	}
+	return nil
+}
+
+// parseRetention parses a retention argument, where "never" (or 0) means
+// events never expire.
+func parseRetention(s string) (int32, error) {
+	if s == "never" {
+		return 0, nil
+	}
+	days, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid retention %q: expected a number of days or \"never\"", s)
+	}
+	opts := groupOptions{retentionDays: int32(days)}
+	return opts.retentionDays, opts.validate()
+}
+
+// shortensRetention reports whether changing a group's retention from
+// current to days (either nil or 0 meaning never expire) deletes events.
+func shortensRetention(current *int32, days int32) bool {
+	if days == 0 {
+		return false
+	}
+	return aws.ToInt32(current) == 0 || days < *current
+}
+
+// logGroupExists checks for a log group with exactly this name.
+func logGroupExists(ctx context.Context, client interfaces.CloudWatchLogsClient, groupName string) (bool, error) {
+	group, err := describeLogGroup(ctx, client, groupName)
+	return group != nil, err
+}
+
+// describeLogGroup returns the log group with exactly this name, or nil if
+// there is none. DescribeLogGroups only supports prefix matching, but it
+// returns groups in name order, so an exact match is always the first result.
+func describeLogGroup(ctx context.Context, client interfaces.CloudWatchLogsClient, groupName string) (*types.LogGroup, error) {
+	input := &cloudwatchlogs.DescribeLogGroupsInput{
+		LogGroupNamePrefix: aws.String(groupName),
+		Limit:              aws.Int32(1),
+	}
+	for g, err := range fetch.LogGroups(ctx, client, input, fetch.PageOptions{Limit: 1}) {
+		if err != nil {
+			return nil, err
+		}
+		if aws.ToString(g.LogGroupName) == groupName {
+			return &g, nil
+		}
+	}
+	return nil, nil
+}
+
+// logStreamExists checks for a log stream with exactly this name. A prefix
+// search alone would treat an existing "foo-2" as proof that "foo" exists.
+// Streams come back in name order, so only the first result is checked.
+func logStreamExists(ctx context.Context, client interfaces.CloudWatchLogsClient, groupName, streamName string) (bool, error) {
+	input := &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName:        aws.String(groupName),
+		LogStreamNamePrefix: aws.String(streamName),
+		Limit:               aws.Int32(1),
+	}
+	for s, err := range fetch.LogStreams(ctx, client, input, fetch.PageOptions{Limit: 1}) {
+		if err != nil {
+			return false, err
+		}
+		if aws.ToString(s.LogStreamName) == streamName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// createLogGroup creates a log group and applies its retention policy.
+func createLogGroup(ctx context.Context, client interfaces.CloudWatchLogsClient, groupName string, opts groupOptions) error {
+	input := &cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String(groupName)}
+	if opts.kmsKeyID != "" {
+		input.KmsKeyId = aws.String(opts.kmsKeyID)
+	}
+	if opts.logClass != "" {
+		input.LogGroupClass = types.LogGroupClass(opts.logClass)
+	}
+	if _, err := client.CreateLogGroup(ctx, input); err != nil {
+		return err
+	}
+	if opts.retentionDays != 0 {
+		_, err := client.PutRetentionPolicy(ctx, &cloudwatchlogs.PutRetentionPolicyInput{
+			LogGroupName:    aws.String(groupName),
+			RetentionInDays: aws.Int32(opts.retentionDays),
+		})
+		return err
+	}
+	return nil
+}
+
+// ensureLogGroupExists creates the group if it is missing. Creation is
+// only attempted when create is set, otherwise a missing group is an error.
+func ensureLogGroupExists(ctx context.Context, client interfaces.CloudWatchLogsClient, groupName string, create bool, opts groupOptions) error {
+	exists, err := logGroupExists(ctx, client, groupName)
+	if err != nil || exists {
+		return err
+	}
+	if !create {
+		return fmt.Errorf("log group %s does not exist (use --create-group to create it)", groupName)
+	}
+	log.Printf("Log group did not exist: %s Creating...", groupName)
+	err = createLogGroup(ctx, client, groupName, opts)
+	if isAlreadyExists(err) {
+		// someone else created it between our check and create
+		return nil
+	}
+	return err
+}
+
+func ensureLogStreamExists(client interfaces.CloudWatchLogsClient, logGroupName, logStreamName string) error {
+	ctx := context.TODO()
+	exists, err := logStreamExists(ctx, client, logGroupName, logStreamName)
+	if err != nil || exists {
+		return err
+	}
+	log.Printf("Log stream did not exist: %s Creating...", logStreamName)
+	_, err = client.CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(logGroupName),
+		LogStreamName: aws.String(logStreamName),
+	})
+	if isAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+func isAlreadyExists(err error) bool {
+	var exists *types.ResourceAlreadyExistsException
+	return errors.As(err, &exists)
+}
+
+// confirm asks a yes/no question and reads the answer from in.
+// Anything other than "y" or "yes" is treated as no. Share one reader
+// across prompts: it may buffer answers piped in for later ones.
+func confirm(in *bufio.Reader, out io.Writer, prompt string) bool {
+	fmt.Fprintf(out, "%s [y/N]: ", prompt)
+	answer, _ := in.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
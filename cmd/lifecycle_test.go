@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// TestEnsureLogStreamExistsExactMatch verifies that an existing stream whose
+// name merely starts with the requested name (foo-2 vs foo) does not prevent
+// the requested stream from being created.
+func TestEnsureLogStreamExistsExactMatch(t *testing.T) {
+	client := &mockPutClient{
+		streamPages: [][]types.LogStream{{{LogStreamName: aws.String("foo-2")}}},
+	}
+	if err := ensureLogStreamExists(client, "group", "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.createdStreams) != 1 || client.createdStreams[0] != "foo" {
+		t.Fatalf("expected stream foo to be created, got %v", client.createdStreams)
+	}
+}
+
+// TestEnsureLogStreamExistsChecksFirstResult verifies that an exact match,
+// which sorts before every other stream with its prefix, is found without
+// paging through the rest.
+func TestEnsureLogStreamExistsChecksFirstResult(t *testing.T) {
+	client := &mockPutClient{
+		streamPages: [][]types.LogStream{
+			{{LogStreamName: aws.String("foo")}},
+			{{LogStreamName: aws.String("foo-1")}},
+		},
+	}
+	if err := ensureLogStreamExists(client, "group", "foo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.createdStreams) != 0 {
+		t.Fatalf("expected no stream creation, got %v", client.createdStreams)
+	}
+	if client.streamCalls != 1 {
+		t.Fatalf("expected 1 DescribeLogStreams call, got %d", client.streamCalls)
+	}
+}
+
+// TestEnsureLogGroupExistsMissing verifies that a missing group is an error
+// unless creation was requested.
+func TestEnsureLogGroupExistsMissing(t *testing.T) {
+	client := &mockPutClient{
+		groupPages: [][]types.LogGroup{{{LogGroupName: aws.String("/app-old")}}},
+	}
+	err := ensureLogGroupExists(context.Background(), client, "/app", false, groupOptions{})
+	if err == nil || !strings.Contains(err.Error(), "--create-group") {
+		t.Fatalf("expected error mentioning --create-group, got %v", err)
+	}
+	if len(client.createdGroups) != 0 {
+		t.Fatal("expected no group to be created")
+	}
+}
+
+// TestEnsureLogGroupExistsCreates verifies that --create-group passes the
+// KMS key and log class through and applies the retention policy.
+func TestEnsureLogGroupExistsCreates(t *testing.T) {
+	client := &mockPutClient{}
+	opts := groupOptions{retentionDays: 30, kmsKeyID: "arn:key", logClass: "INFREQUENT_ACCESS"}
+	if err := ensureLogGroupExists(context.Background(), client, "/app", true, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.createdGroups) != 1 {
+		t.Fatalf("expected 1 group created, got %d", len(client.createdGroups))
+	}
+	created := client.createdGroups[0]
+	if aws.ToString(created.KmsKeyId) != "arn:key" || created.LogGroupClass != types.LogGroupClassInfrequentAccess {
+		t.Fatalf("unexpected create input: %+v", created)
+	}
+	if client.retention["/app"] != 30 {
+		t.Fatalf("expected retention 30, got %d", client.retention["/app"])
+	}
+}
+
+func TestGroupOptionsValidate(t *testing.T) {
+	if err := (groupOptions{retentionDays: 31}).validate(); err == nil {
+		t.Error("expected error for invalid retention")
+	}
+	if err := (groupOptions{logClass: "COLD"}).validate(); err == nil {
+		t.Error("expected error for invalid log class")
+	}
+	if err := (groupOptions{retentionDays: 14, logClass: "STANDARD"}).validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParseRetention(t *testing.T) {
+	if days, err := parseRetention("never"); err != nil || days != 0 {
+		t.Fatalf("expected 0, nil; got %d, %v", days, err)
+	}
+	if days, err := parseRetention("90"); err != nil || days != 90 {
+		t.Fatalf("expected 90, nil; got %d, %v", days, err)
+	}
+	if _, err := parseRetention("forever"); err == nil {
+		t.Fatal("expected error for non-numeric retention")
+	}
+}
+
+// TestShortensRetention verifies that only a shorter retention counts as
+// deleting events, with never-expire as the longest.
+func TestShortensRetention(t *testing.T) {
+	for _, tc := range []struct {
+		current *int32
+		days    int32
+		want    bool
+	}{
+		{nil, 30, true},
+		{aws.Int32(0), 30, true},
+		{aws.Int32(90), 30, true},
+		{aws.Int32(30), 30, false},
+		{aws.Int32(30), 90, false},
+		{aws.Int32(30), 0, false},
+		{nil, 0, false},
+	} {
+		if got := shortensRetention(tc.current, tc.days); got != tc.want {
+			t.Errorf("shortensRetention(%v, %d) = %v, want %v", aws.ToInt32(tc.current), tc.days, got, tc.want)
+		}
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	var out bytes.Buffer
+	if !confirm(bufio.NewReader(strings.NewReader("yes\n")), &out, "Delete?") {
+		t.Error("expected yes to confirm")
+	}
+	if confirm(bufio.NewReader(strings.NewReader("\n")), &out, "Delete?") {
+		t.Error("expected empty answer to decline")
+	}
+	// piped answers to several prompts are read one per prompt
+	answers := bufio.NewReader(strings.NewReader("y\nn\ny\n"))
+	for i, want := range []bool{true, false, true} {
+		if got := confirm(answers, &out, "Delete?"); got != want {
+			t.Errorf("answer %d = %v, want %v", i, got, want)
+		}
+	}
+	if !strings.Contains(out.String(), "Delete? [y/N]") {
+		t.Errorf("expected prompt in output, got %q", out.String())
+	}
+}
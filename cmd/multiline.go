@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// defaultMaxMessageSize keeps a message plus the per-event overhead within
+// the 256 KiB event limit.
+const defaultMaxMessageSize = maxEventSize - eventOverhead
+
+// aggregateLines turns a channel of line events into a channel of log
+// events. When start is set, a line matching it begins a new event and any
+// other line is appended (newline-separated) to the current one, so a stack
+// trace stays a single event stamped with the time of its first line. The
+// current event is emitted when the next one starts, after timeout passes
+// with no new lines, or when the input closes. Messages longer than maxSize
+// are split into several events.
+func aggregateLines(in <-chan types.InputLogEvent, start *regexp.Regexp, timeout time.Duration, maxSize int) <-chan types.InputLogEvent {
+	if maxSize <= 0 {
+		maxSize = defaultMaxMessageSize
+	}
+	out := make(chan types.InputLogEvent, cap(in))
+	go func() {
+		defer close(out)
+		var current *strings.Builder
+		var ts *int64
+		emit := func() {
+			if current == nil {
+				return
+			}
+			for _, part := range splitMessage(current.String(), maxSize) {
+				out <- types.InputLogEvent{Message: aws.String(part), Timestamp: ts}
+			}
+			current = nil
+		}
+
+		var timer <-chan time.Time
+		for {
+			select {
+			case e, ok := <-in:
+				if !ok {
+					emit()
+					return
+				}
+				line := aws.ToString(e.Message)
+				if start == nil || current == nil || start.MatchString(line) {
+					emit()
+					current = &strings.Builder{}
+					current.WriteString(line)
+					ts = e.Timestamp
+				} else if current.Len()+1+len(line) > maxSize {
+					// continuation would overflow the event; start a new one
+					// rather than splitting mid-line
+					emit()
+					current = &strings.Builder{}
+					current.WriteString(line)
+				} else {
+					current.WriteByte('\n')
+					current.WriteString(line)
+				}
+				if start == nil {
+					emit()
+				} else if timeout > 0 {
+					timer = time.After(timeout)
+				}
+			case <-timer:
+				emit()
+				timer = nil
+			}
+		}
+	}()
+	return out
+}
+
+// splitMessage splits s into chunks of at most maxSize bytes without
+// breaking UTF-8 sequences.
+func splitMessage(s string, maxSize int) []string {
+	if len(s) <= maxSize {
+		return []string{s}
+	}
+	var parts []string
+	for len(s) > maxSize {
+		cut := maxSize
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		if cut == 0 {
+			cut = maxSize
+		}
+		parts = append(parts, s[:cut])
+		s = s[cut:]
+	}
+	if len(s) > 0 {
+		parts = append(parts, s)
+	}
+	return parts
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func aggregateAll(lines []string, start *regexp.Regexp, maxSize int) []types.InputLogEvent {
+	in := make(chan types.InputLogEvent, len(lines))
+	for i, line := range lines {
+		in <- inputEvent(line, int64(i))
+	}
+	close(in)
+	var result []types.InputLogEvent
+	for e := range aggregateLines(in, start, time.Hour, maxSize) {
+		result = append(result, e)
+	}
+	return result
+}
+
+// TestAggregateStackTrace verifies that continuation lines are joined to the
+// event started by the previous matching line, keeping its timestamp.
+func TestAggregateStackTrace(t *testing.T) {
+	lines := []string{
+		"2024-01-02 10:00:00 ERROR boom",
+		"java.lang.RuntimeException: boom",
+		"    at com.example.Foo.bar(Foo.java:10)",
+		"2024-01-02 10:00:01 INFO recovered",
+	}
+	got := aggregateAll(lines, regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`), 0)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(got))
+	}
+	if *got[0].Message != strings.Join(lines[:3], "\n") {
+		t.Fatalf("unexpected first event %q", *got[0].Message)
+	}
+	if *got[0].Timestamp != 0 || *got[1].Timestamp != 3 {
+		t.Fatalf("expected timestamps of the starting lines, got %d and %d", *got[0].Timestamp, *got[1].Timestamp)
+	}
+}
+
+// TestAggregateWithoutPattern verifies that each line is its own event when
+// no start pattern is configured.
+func TestAggregateWithoutPattern(t *testing.T) {
+	got := aggregateAll([]string{"a", "  b", "c"}, nil, 0)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(got))
+	}
+}
+
+// TestAggregateMaxSize verifies that a continuation that would overflow the
+// event starts a new event, and that a single long line is split.
+func TestAggregateMaxSize(t *testing.T) {
+	start := regexp.MustCompile(`^START`)
+	got := aggregateAll([]string{"START", "1234", "5678"}, start, 10)
+	if len(got) != 2 || *got[0].Message != "START\n1234" || *got[1].Message != "5678" {
+		t.Fatalf("unexpected events %v", messages(got))
+	}
+
+	got = aggregateAll([]string{strings.Repeat("x", 25)}, nil, 10)
+	if len(got) != 3 {
+		t.Fatalf("expected long line split into 3 events, got %v", messages(got))
+	}
+}
+
+// TestAggregateTimeout verifies that the last event is emitted after the
+// timeout even though the input stays open.
+func TestAggregateTimeout(t *testing.T) {
+	in := make(chan types.InputLogEvent)
+	out := aggregateLines(in, regexp.MustCompile(`^START`), 10*time.Millisecond, 0)
+	in <- inputEvent("START", 1)
+	in <- inputEvent("more", 2)
+	select {
+	case e := <-out:
+		if *e.Message != "START\nmore" {
+			t.Fatalf("unexpected event %q", *e.Message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected event to be flushed after timeout")
+	}
+	close(in)
+}
+
+// TestSplitMessageUTF8 verifies that splitting never cuts a multi-byte rune.
+func TestSplitMessageUTF8(t *testing.T) {
+	parts := splitMessage(strings.Repeat("é", 10), 5) // 2 bytes each
+	for _, p := range parts {
+		if !utf8.ValidString(p) || len(p) > 5 {
+			t.Fatalf("invalid part %q", p)
+		}
+	}
+	if strings.Join(parts, "") != strings.Repeat("é", 10) {
+		t.Fatal("parts don't reassemble to the original message")
+	}
+}
+
+func messages(events []types.InputLogEvent) []string {
+	result := make([]string, len(events))
+	for i, e := range events {
+		result[i] = aws.ToString(e.Message)
+	}
+	return result
+}
@@ -3,10 +3,14 @@ package cmd
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/signal"
+	"regexp"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/derricw/cwl/arn"
@@ -15,43 +19,180 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 )
 
+var flushInterval time.Duration
+var timestampField string
+var timestampRegex string
+var timestampFormat string
+var createGroup bool
+var putFiles []string
+var putGroup string
+var followFiles bool
+var streamTemplate string
+var stateFile string
+var multilineStart string
+var multilineTimeout time.Duration
+var maxMessageSize int
+var emfMode bool
+var emfNamespace string
+var emfDimensions []string
+var emfDimensionSets []string
+
 func init() {
+	putCmd.PersistentFlags().StringVar(&timestampField, "timestamp-field", "", "JSON field (dotted path) holding each event's timestamp")
+	putCmd.PersistentFlags().StringVar(&timestampRegex, "timestamp-regex", "", "Regex locating each line's timestamp (uses the named group 'ts', else the first group)")
+	putCmd.PersistentFlags().StringVar(&timestampFormat, "timestamp-format", "rfc3339", "Timestamp format: rfc3339, unix, unixms, or a Go time layout")
+	putCmd.PersistentFlags().DurationVar(&flushInterval, "flush-interval", defaultFlushTime, "Maximum time to buffer events before uploading a partial batch")
+	putCmd.PersistentFlags().BoolVar(&createGroup, "create-group", false, "Create the log group if it does not exist")
+	putCmd.PersistentFlags().StringArrayVar(&putFiles, "file", nil, "Ship files matching this glob instead of stdin (repeatable)")
+	putCmd.PersistentFlags().StringVarP(&putGroup, "group", "g", "", "Log group to ship --file to")
+	putCmd.PersistentFlags().BoolVarP(&followFiles, "follow", "f", false, "Keep tailing --file, handling rotation and truncation")
+	putCmd.PersistentFlags().StringVar(&streamTemplate, "stream-template", defaultStreamTemplate, "Stream name for each --file; supports {hostname}, {filename} and {path}")
+	putCmd.PersistentFlags().StringVar(&stateFile, "state-file", "", "Persist --file read offsets here so restarts resume where they left off")
+	putCmd.PersistentFlags().StringVar(&multilineStart, "multiline-start", "", "Regex matching the first line of an event; other lines are appended to the current event")
+	putCmd.PersistentFlags().DurationVar(&multilineTimeout, "multiline-timeout", time.Second, "Emit a multi-line event after this long without new lines")
+	putCmd.PersistentFlags().IntVar(&maxMessageSize, "max-event-size", defaultMaxMessageSize, "Split events larger than this many bytes")
+	putCmd.PersistentFlags().BoolVar(&emfMode, "emf", false, "Treat arguments (or stdin lines) as metrics and write Embedded Metric Format documents")
+	putCmd.PersistentFlags().StringVar(&emfNamespace, "namespace", "", "CloudWatch metric namespace for --emf")
+	putCmd.PersistentFlags().StringArrayVar(&emfDimensions, "dimension", nil, "Metric dimension key=value for --emf (repeatable)")
+	putCmd.PersistentFlags().StringArrayVar(&emfDimensionSets, "dimension-set", nil, "Comma-separated dimension keys forming one dimension set for --emf (repeatable, default: all dimensions)")
+	addGroupOptionFlags(putCmd)
 	rootCmd.AddCommand(putCmd)
 }
 
-func ensureLogStreamExists(client interfaces.CloudWatchLogsClient, logGroupName, logStreamName string) error {
-	// Try to describe the log stream to check if it exists
-	output, err := client.DescribeLogStreams(context.TODO(), &cloudwatchlogs.DescribeLogStreamsInput{
-		LogGroupName:        aws.String(logGroupName),
-		LogStreamNamePrefix: aws.String(logStreamName),
-	})
-
-	if err == nil && len(output.LogStreams) == 0 {
-		log.Printf("Log stream did not exist: %s Creating...", logStreamName)
-		_, err = client.CreateLogStream(context.TODO(), &cloudwatchlogs.CreateLogStreamInput{
-			LogGroupName:  aws.String(logGroupName),
-			LogStreamName: aws.String(logStreamName),
-		})
-		return err
-	}
-	return err
-}
-
 var putCmd = &cobra.Command{
-	Use:   "put [stream arn] [event]",
+	Use:   "put [stream arn] [event] | put --file GLOB --group G",
 	Short: "put events for log stream",
-	Long:  `Put events for a log stream. Can stream events from stdin`,
-	Args:  cobra.MatchAll(cobra.MinimumNArgs(1)),
+	Long: `Put events for a log stream. Can stream events from stdin, or ship files
+with --file.
+Events are uploaded in batches of up to 10,000 events or 1 MiB. When reading
+from a slow stream, a partial batch is flushed every --flush-interval.
+
+By default each event is stamped with the time it was read. To preserve the
+timeline of an existing log file, extract timestamps from the input with
+--timestamp-field (JSON lines) or --timestamp-regex (text lines). Lines
+without a timestamp reuse the previous line's timestamp.
+
+Use --multiline-start to keep multi-line records such as stack traces in a
+single event: lines matching the regex start a new event and all other lines
+are appended to the current one. Events over --max-event-size are split.
+
+With --emf, each argument after the stream ARN is a metric in the form
+name=value or name=value[Unit], written as an Embedded Metric Format
+document so CloudWatch extracts it as a metric. Without metric arguments,
+each stdin line is a space-separated list of metrics and becomes its own
+document. Documents are validated locally before upload.
+
+With --file, each matching file is shipped to its own stream in --group,
+named from --stream-template. With --follow, files are tailed like tail -F:
+renamed and truncated files are detected, new files matching the glob are
+picked up, and read offsets are saved to --state-file after every upload.`,
+	Example: `
+Re-upload a JSON log file using its "time" field:
+
+    cwl put $ARN --timestamp-field time < app.jsonl
+
+Re-upload a text log whose lines start with "2024-01-02 15:04:05":
+
+    cwl put $ARN --timestamp-regex '^(\S+ \S+)' --timestamp-format '2006-01-02 15:04:05' < app.log
+
+Keep Java/Python stack traces together, one event per timestamped record:
+
+    cwl put $ARN --multiline-start '^\d{4}-\d{2}-\d{2}' < app.log
+
+Emit metrics from a script as Embedded Metric Format:
+
+    cwl put $ARN --emf --namespace MyApp --dimension Service=api 'latency=12.5[Milliseconds]' errors=0
+
+Ship files as a small log agent, one stream per file, surviving restarts:
+
+    cwl put --file '/var/log/app/*.log' --group /app --follow --state-file ~/.cwl/put-state.json
+  `,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(putFiles) > 0 {
+			if putGroup == "" {
+				return fmt.Errorf("--file requires --group")
+			}
+			if len(args) != 0 {
+				return fmt.Errorf("cannot provide a stream ARN when using --file")
+			}
+			if multilineStart != "" {
+				return fmt.Errorf("--multiline-start is not supported with --file")
+			}
+			return nil
+		}
+		if followFiles {
+			return fmt.Errorf("--follow requires --file")
+		}
+		if emfMode {
+			if emfNamespace == "" {
+				return fmt.Errorf("--emf requires --namespace")
+			}
+			if len(args) < 1 {
+				return fmt.Errorf("expected a stream ARN followed by metrics")
+			}
+			return nil
+		}
+		if maxMessageSize <= 0 || maxMessageSize > defaultMaxMessageSize {
+			return fmt.Errorf("--max-event-size must be between 1 and %d", defaultMaxMessageSize)
+		}
+		if len(args) < 1 || len(args) > 2 {
+			return fmt.Errorf("expected a stream ARN and an optional event")
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 
 		client, err := fetch.CreateClient(awsProfile)
 		if err != nil {
 			log.Fatal(err)
 		}
+		opts := groupOptionsFromFlags()
+		if err := opts.validate(); err != nil {
+			log.Fatal(err)
+		}
+		extractor, err := newTimestampExtractor(timestampField, timestampRegex, timestampFormat)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var startPattern *regexp.Regexp
+		if multilineStart != "" {
+			if startPattern, err = regexp.Compile(multilineStart); err != nil {
+				log.Fatalf("invalid --multiline-start: %v", err)
+			}
+		}
+
+		if len(putFiles) > 0 {
+			if err := ensureLogGroupExists(context.TODO(), client, putGroup, createGroup, opts); err != nil {
+				log.Fatal(err)
+			}
+			state, err := loadShipState(stateFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			shipper := newFileShipper(client, putGroup, putFiles, streamTemplate, state)
+			shipper.newExtractor = func() *timestampExtractor {
+				// each file keeps its own "previous line" fallback
+				x, _ := newTimestampExtractor(timestampField, timestampRegex, timestampFormat)
+				return x
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if err := shipper.Run(ctx, followFiles); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		if emfMode {
+			streamId := arn.ParseStreamArn(args[0])
+			if err := putEMF(client, streamId.GroupName, streamId.StreamName, args[1:], os.Stdin, opts); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
 
 		var readFrom io.Reader
 		var streamArn string
@@ -60,36 +201,123 @@ var putCmd = &cobra.Command{
 			// read events from stdin
 			streamArn = args[0]
 			readFrom = os.Stdin
-		} else if len(args) == 2 {
+		} else {
 			streamArn = args[0]
 			readFrom = strings.NewReader(args[1])
-		} else {
-			log.Fatal("Expected no more than 2 args")
 		}
 		streamId := arn.ParseStreamArn(streamArn)
+		err = ensureLogGroupExists(context.TODO(), client, streamId.GroupName, createGroup, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
 		err = ensureLogStreamExists(client, streamId.GroupName, streamId.StreamName)
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		scanner := bufio.NewScanner(readFrom)
+		lines := make(chan types.InputLogEvent, maxBatchEvents)
+		var readErr error
+		go func() {
+			defer close(lines)
+			readErr = readInput(readFrom, extractor, lines)
+		}()
+
+		events := aggregateLines(lines, startPattern, multilineTimeout, maxMessageSize)
+		err = putEvents(context.Background(), client, streamId.GroupName, streamId.StreamName, events, flushInterval)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// lines is closed once putEvents returns, so readErr is set
+		if readErr != nil {
+			log.Fatalf("error reading input: %v", readErr)
+		}
+	},
+}
+
+// readInput sends each line of r as an event, stamped by extractor when it
+// is set. Lines of any length are read whole; aggregateLines splits those
+// too big for one event.
+func readInput(r io.Reader, extractor *timestampExtractor, out chan<- types.InputLogEvent) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+			ts := time.Now()
+			if extractor != nil {
+				ts = extractor.Extract(line)
+			}
+			out <- types.InputLogEvent{
+				Message:   aws.String(line),
+				Timestamp: aws.Int64(ts.UnixMilli()),
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// putEMF renders metrics as EMF documents and uploads them. Metrics come
+// from args, or one document per line of in when args is empty.
+func putEMF(client interfaces.CloudWatchLogsClient, groupName, streamName string, args []string, in io.Reader, opts groupOptions) error {
+	doc := emfDocument{Namespace: emfNamespace}
+	for _, spec := range emfDimensions {
+		dim, err := parseEMFDimension(spec)
+		if err != nil {
+			return err
+		}
+		doc.Dimensions = append(doc.Dimensions, dim)
+	}
+	for _, set := range emfDimensionSets {
+		doc.DimensionSets = append(doc.DimensionSets, strings.Split(set, ","))
+	}
+
+	var specLines [][]string
+	if len(args) > 0 {
+		specLines = [][]string{args}
+	} else {
+		scanner := bufio.NewScanner(in)
 		for scanner.Scan() {
-			event := scanner.Text()
-			input := &cloudwatchlogs.PutLogEventsInput{
-				LogGroupName:  aws.String(streamId.GroupName),
-				LogStreamName: aws.String(streamId.StreamName),
-				LogEvents: []types.InputLogEvent{
-					// TODO: batching is supported up to 10000 events or 1MB
-					{
-						Message:   aws.String(event),
-						Timestamp: aws.Int64(time.Now().UnixNano() / 1000000), // Convert to milliseconds
-					},
-				},
+			if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+				specLines = append(specLines, fields)
 			}
-			_, err = client.PutLogEvents(context.TODO(), input)
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	// validate everything before uploading anything
+	var events []types.InputLogEvent
+	for _, specs := range specLines {
+		metrics := make([]emfMetric, len(specs))
+		for i, spec := range specs {
+			m, err := parseEMFMetric(spec)
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
+			metrics[i] = m
 		}
-	},
+		now := time.Now()
+		docs, err := doc.Render(metrics, now)
+		if err != nil {
+			return err
+		}
+		for _, d := range docs {
+			events = append(events, types.InputLogEvent{Message: aws.String(d), Timestamp: aws.Int64(now.UnixMilli())})
+		}
+	}
+
+	ctx := context.TODO()
+	if err := ensureLogGroupExists(ctx, client, groupName, createGroup, opts); err != nil {
+		return err
+	}
+	if err := ensureLogStreamExists(client, groupName, streamName); err != nil {
+		return err
+	}
+	return putAll(ctx, client, groupName, streamName, events)
 }
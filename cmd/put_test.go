@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// TestReadInputLongLine verifies that a line longer than a whole batch is
+// read, split into events that each fit the API limit, and that the input
+// after it is still read.
+func TestReadInputLongLine(t *testing.T) {
+	long := strings.Repeat("x", maxBatchBytes+10)
+	lines := make(chan types.InputLogEvent, 10)
+	var err error
+	go func() {
+		defer close(lines)
+		err = readInput(strings.NewReader("first\n"+long+"\nlast"), nil, lines)
+	}()
+
+	var got []string
+	for e := range aggregateLines(lines, nil, 0, 0) {
+		got = append(got, aws.ToString(e.Message))
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) < 3 || got[0] != "first" || got[len(got)-1] != "last" {
+		t.Fatalf("unexpected events: %d, first %.10q, last %.10q", len(got), got[0], got[len(got)-1])
+	}
+	total := 0
+	for _, m := range got[1 : len(got)-1] {
+		if len(m)+eventOverhead > maxEventSize {
+			t.Errorf("event of %d bytes exceeds the event limit", len(m))
+		}
+		total += len(m)
+	}
+	if total != len(long) {
+		t.Errorf("long line split into %d bytes, want %d", total, len(long))
+	}
+}
@@ -15,6 +15,9 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 )
 
+// maxQueryLogGroups is the most log groups StartQuery accepts by name.
+const maxQueryLogGroups = 50
+
 var queryString string
 var startTime int64
 var endTime int64
@@ -23,6 +26,7 @@ func init() {
 	queryCmd.PersistentFlags().StringVarP(&queryString, "query", "q", "", "Query string.")
 	queryCmd.PersistentFlags().Int64VarP(&startTime, "start-time", "s", 0, "Start time. Unix timestamp.")
 	queryCmd.PersistentFlags().Int64VarP(&endTime, "end-time", "e", time.Now().Unix(), "End time. Unix timestamp.")
+	queryCmd.PersistentFlags().StringArrayVar(&tagSelectors, "tag", nil, "Query the log groups with this tag, as key=value or just key (repeatable)")
 	rootCmd.AddCommand(queryCmd)
 }
 
@@ -57,8 +61,17 @@ Query all log groups:
 Pass in a specific time range:
 
     cwl query -q "fields @timestamp, @message" -s $(date -d "2 weeks ago" +%s) -e $(date -d "yesterday" +%s)
+
+Query every log group tagged team=payments:
+
+    cwl query --tag team=payments -q "fields @timestamp, @message | limit 20"
   `,
-	Args: cobra.MatchAll(cobra.MaximumNArgs(1)),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(tagSelectors) > 0 && len(args) > 0 {
+			return fmt.Errorf("pass either log groups or --tag, not both")
+		}
+		return cobra.MaximumNArgs(1)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 
 		client, err := fetch.CreateClient(awsProfile)
@@ -72,7 +85,16 @@ Pass in a specific time range:
 			EndTime:   &endTime,
 		}
 
-		if len(args) == 0 {
+		if len(tagSelectors) > 0 {
+			names, err := selectGroupsByTag(context.TODO(), client)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if len(names) > maxQueryLogGroups {
+				log.Fatalf("--tag matches %d log groups, but a query can cover at most %d", len(names), maxQueryLogGroups)
+			}
+			startQueryInput.LogGroupNames = names
+		} else if len(args) == 0 {
 			// only way currently to query all log groups is to use SOURCE query
 			queryString = "SOURCE logGroups() | " + queryString
 		} else {
@@ -91,25 +113,21 @@ Pass in a specific time range:
 		log.Println("Query started, ID:", *queryID)
 
 		// Poll for query results
-		var queryResults *cloudwatchlogs.GetQueryResultsOutput
-		for {
-			time.Sleep(2 * time.Second) // Wait before polling
-
-			queryResults, err = client.GetQueryResults(ctx, &cloudwatchlogs.GetQueryResultsInput{
-				QueryId: queryID,
-			})
+		var results [][]types.ResultField
+		opts := fetch.QueryOptions{
+			Interval: 2 * time.Second,
+			OnStatus: func(status types.QueryStatus) {
+				log.Println("Waiting for query to complete... Status:", status)
+			},
+		}
+		for row, err := range fetch.QueryResults(ctx, client, *queryID, opts) {
 			if err != nil {
 				log.Fatal("Failed to get query results:", err)
 			}
-
-			if queryResults.Status == types.QueryStatusComplete {
-				break
-			}
-
-			log.Println("Waiting for query to complete... Status:", queryResults.Status)
+			results = append(results, row)
 		}
 
-		jsonResults, err := queryResultsToJSON(queryResults.Results)
+		jsonResults, err := queryResultsToJSON(results)
 		if err != nil {
 			log.Fatal("Failed to marshal query results: ", err)
 		}
@@ -56,6 +56,34 @@ func (m *MockQueryClient) PutLogEvents(ctx context.Context, params *cloudwatchlo
 	return nil, nil
 }
 
+func (m *MockQueryClient) CreateLogGroup(ctx context.Context, params *cloudwatchlogs.CreateLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	return nil, nil
+}
+
+func (m *MockQueryClient) DeleteLogGroup(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error) {
+	return nil, nil
+}
+
+func (m *MockQueryClient) PutRetentionPolicy(ctx context.Context, params *cloudwatchlogs.PutRetentionPolicyInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+	return nil, nil
+}
+
+func (m *MockQueryClient) DeleteRetentionPolicy(ctx context.Context, params *cloudwatchlogs.DeleteRetentionPolicyInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteRetentionPolicyOutput, error) {
+	return nil, nil
+}
+
+func (m *MockQueryClient) ListTagsForResource(ctx context.Context, params *cloudwatchlogs.ListTagsForResourceInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.ListTagsForResourceOutput, error) {
+	return nil, nil
+}
+
+func (m *MockQueryClient) TagResource(ctx context.Context, params *cloudwatchlogs.TagResourceInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.TagResourceOutput, error) {
+	return nil, nil
+}
+
+func (m *MockQueryClient) UntagResource(ctx context.Context, params *cloudwatchlogs.UntagResourceInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.UntagResourceOutput, error) {
+	return nil, nil
+}
+
 func TestQueryResultsToJSON(t *testing.T) {
 	timestamp, message := "@timestamp", "@message"
 	timestampVal, messageVal := "2023-01-01T10:00:00Z", "Test message"
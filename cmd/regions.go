@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/derricw/cwl/fetch"
+	"github.com/derricw/cwl/interfaces"
+)
+
+// allRegions are the commercial regions CloudWatch Logs is available in,
+// used for --regions all. Opt-in regions that aren't enabled for an account
+// fail with an auth error, which is reported and skipped.
+var allRegions = []string{
+	"us-east-1", "us-east-2", "us-west-1", "us-west-2",
+	"af-south-1",
+	"ap-east-1", "ap-south-1", "ap-south-2", "ap-southeast-1", "ap-southeast-2",
+	"ap-southeast-3", "ap-southeast-4", "ap-southeast-5", "ap-southeast-7",
+	"ap-northeast-1", "ap-northeast-2", "ap-northeast-3",
+	"ca-central-1", "ca-west-1",
+	"eu-central-1", "eu-central-2", "eu-west-1", "eu-west-2", "eu-west-3",
+	"eu-south-1", "eu-south-2", "eu-north-1",
+	"il-central-1", "me-south-1", "me-central-1",
+	"mx-central-1", "sa-east-1",
+}
+
+// newRegionalClient creates the client for one profile and region. It is a
+// variable so tests can fan out over mock clients.
+var newRegionalClient = fetch.CreateRegionalClient
+
+// awsTarget is one profile/region pair to query. An empty profile or region
+// means the default.
+type awsTarget struct {
+	profile string
+	region  string
+}
+
+// parseTargets expands comma-separated --profiles and --regions values into
+// every profile/region combination, in the order given.
+func parseTargets(profiles, regions string) ([]awsTarget, error) {
+	profileList := splitList(profiles)
+	if len(profileList) == 0 {
+		profileList = []string{awsProfile}
+	}
+	regionList := splitList(regions)
+	switch {
+	case len(regionList) == 0:
+		regionList = []string{""}
+	case len(regionList) == 1 && regionList[0] == "all":
+		regionList = allRegions
+	default:
+		for _, r := range regionList {
+			if r == "all" {
+				return nil, fmt.Errorf("--regions all can't be combined with other regions")
+			}
+		}
+	}
+	var targets []awsTarget
+	for _, p := range profileList {
+		for _, r := range regionList {
+			targets = append(targets, awsTarget{profile: p, region: r})
+		}
+	}
+	return targets, nil
+}
+
+func splitList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// client creates the target's client and fills in the resolved region.
+func (t *awsTarget) client() (interfaces.CloudWatchLogsClient, error) {
+	client, region, err := newRegionalClient(t.profile, t.region)
+	if err != nil {
+		return nil, err
+	}
+	t.region = region
+	return client, nil
+}
+
+// accountFromArn extracts the account ID from an ARN
+// (arn:partition:service:region:account:resource).
+func accountFromArn(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 {
+		return ""
+	}
+	return parts[4]
+}
@@ -54,6 +54,27 @@ func (m *mockEventsClient) StartQuery(ctx context.Context, params *cloudwatchlog
 func (m *mockEventsClient) GetQueryResults(ctx context.Context, params *cloudwatchlogs.GetQueryResultsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetQueryResultsOutput, error) {
 	return nil, nil
 }
+func (m *mockEventsClient) CreateLogGroup(ctx context.Context, params *cloudwatchlogs.CreateLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	return nil, nil
+}
+func (m *mockEventsClient) DeleteLogGroup(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error) {
+	return nil, nil
+}
+func (m *mockEventsClient) PutRetentionPolicy(ctx context.Context, params *cloudwatchlogs.PutRetentionPolicyInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+	return nil, nil
+}
+func (m *mockEventsClient) DeleteRetentionPolicy(ctx context.Context, params *cloudwatchlogs.DeleteRetentionPolicyInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteRetentionPolicyOutput, error) {
+	return nil, nil
+}
+func (m *mockEventsClient) ListTagsForResource(ctx context.Context, params *cloudwatchlogs.ListTagsForResourceInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.ListTagsForResourceOutput, error) {
+	return nil, nil
+}
+func (m *mockEventsClient) TagResource(ctx context.Context, params *cloudwatchlogs.TagResourceInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.TagResourceOutput, error) {
+	return nil, nil
+}
+func (m *mockEventsClient) UntagResource(ctx context.Context, params *cloudwatchlogs.UntagResourceInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.UntagResourceOutput, error) {
+	return nil, nil
+}
 
 func makeEvents(n int) []types.OutputLogEvent {
 	events := make([]types.OutputLogEvent, n)
@@ -202,3 +223,35 @@ func TestRequestEventsEmptyPagesResetOnData(t *testing.T) {
 		t.Fatalf("expected 5 events, got %d", len(got))
 	}
 }
+
+// nilTokenClient returns events without a NextForwardToken.
+type nilTokenClient struct {
+	mockEventsClient
+}
+
+func (m *nilTokenClient) GetLogEvents(ctx context.Context, params *cloudwatchlogs.GetLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetLogEventsOutput, error) {
+	m.call++
+	return &cloudwatchlogs.GetLogEventsOutput{Events: makeEvents(3)}, nil
+}
+
+// TestRequestEventsNilForwardToken verifies that a response without a
+// NextForwardToken ends the stream, rather than rereading it from the start
+// or dereferencing the missing token.
+func TestRequestEventsNilForwardToken(t *testing.T) {
+	oldFollow := follow
+	follow = false
+	defer func() { follow = oldFollow }()
+
+	client := &nilTokenClient{}
+	ch := make(chan Event, 10000)
+	if err := requestEvents(client, "group", "stream", ch, nil, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	close(ch)
+	if got := collectEvents(ch); len(got) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(got))
+	}
+	if client.call != 1 {
+		t.Fatalf("expected 1 API call, got %d", client.call)
+	}
+}
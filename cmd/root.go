@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -10,8 +11,16 @@ import (
 
 	"github.com/derricw/cwl/model"
 	"github.com/derricw/cwl/provider"
-	"github.com/derricw/cwl/provider/cloudwatch"
-	"github.com/derricw/cwl/provider/mlflow"
+	"github.com/derricw/cwl/provider/composite"
+
+	// backends register their URI schemes with provider.Register
+	_ "github.com/derricw/cwl/provider/cloudwatch"
+	_ "github.com/derricw/cwl/provider/docker"
+	_ "github.com/derricw/cwl/provider/kubernetes"
+	_ "github.com/derricw/cwl/provider/local"
+	_ "github.com/derricw/cwl/provider/loki"
+	_ "github.com/derricw/cwl/provider/mlflow"
+	_ "github.com/derricw/cwl/provider/opensearch"
 )
 
 var jsonOutput bool
@@ -20,6 +29,8 @@ var logGroup string
 var streamFilter string
 var mlflowURL string
 var mlflowARN string
+var localDir string
+var sources []string
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&awsProfile, "profile", "p", "", "AWS Profile to use")
@@ -27,29 +38,99 @@ func init() {
 	rootCmd.Flags().StringVarP(&streamFilter, "stream-filter", "s", "", "Filter streams by name (requires -g)")
 	rootCmd.Flags().StringVar(&mlflowURL, "mlflow-url", "", "MLflow tracking server URL (implies mlflow backend)")
 	rootCmd.Flags().StringVar(&mlflowARN, "mlflow-arn", "", "SageMaker MLflow tracking server ARN (implies mlflow backend)")
+	rootCmd.Flags().StringVar(&localDir, "dir", "", "Browse log files in a local directory instead of CloudWatch (e.g. a cwl export dump or /var/log)")
+	rootCmd.Flags().StringArrayVar(&sources, "source", nil, "Log source URI, e.g. cloudwatch://profile@us-west-2, mlflow+https://host, docker://, file:///path (env CWL_SOURCE). Repeat to browse several sources, optionally named as name=URI")
 }
 
-// createBackend resolves the backend from flags and env vars.
-// Priority: --mlflow-arn > --mlflow-url > MLFLOW_TRACKING_URI env > CloudWatch default.
+// createBackend opens the backend for the sources chosen by flags and env
+// vars. Several --source flags are browsed together, with each source's
+// groups listed under its name.
 func createBackend() (provider.Backend, error) {
-	// Explicit flags take priority
-	if mlflowARN != "" {
-		return mlflow.NewFromSageMakerARN(mlflowARN, awsProfile)
+	opts := provider.OpenOptions{Profile: awsProfile}
+	if len(sources) <= 1 {
+		return provider.Open(sourceURI(), opts)
+	}
+	children := make([]composite.Source, 0, len(sources))
+	for _, s := range parseSources(sources) {
+		backend, err := provider.Open(s.uri, opts)
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %w", s.name, err)
+		}
+		children = append(children, composite.Source{Name: s.name, Backend: backend})
+	}
+	return composite.New(children...)
+}
+
+// namedSource is a --source value, which may be prefixed with name=.
+type namedSource struct {
+	name string
+	uri  string
+}
+
+var sourceNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.@-]+$`)
+
+// parseSources splits name= prefixes off --source values, naming the rest
+// after their URI. Repeated names get a numeric suffix.
+func parseSources(args []string) []namedSource {
+	result := make([]namedSource, len(args))
+	seen := map[string]int{}
+	for i, arg := range args {
+		name, uri, ok := strings.Cut(arg, "=")
+		if !ok || !sourceNamePattern.MatchString(name) {
+			name, uri = defaultSourceName(arg), arg
+		}
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name = fmt.Sprintf("%s-%d", name, n)
+		}
+		result[i] = namedSource{name: name, uri: uri}
+	}
+	return result
+}
+
+// defaultSourceName names a source after the host or profile in its URI,
+// e.g. prod@us-east-1 for cloudwatch://prod@us-east-1, or after its scheme
+// when there is none (file:///var/log, or an ARN).
+func defaultSourceName(uri string) string {
+	scheme, rest, _ := strings.Cut(uri, "://")
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		rest = rest[:i]
 	}
-	if mlflowURL != "" {
-		return mlflow.New(mlflowURL), nil
+	if userinfo, host, ok := strings.Cut(rest, "@"); ok && strings.Contains(userinfo, ":") {
+		rest = host // drop credentials
 	}
+	rest, _, _ = strings.Cut(rest, ":")
+	if rest == "" || rest == "arn" || !sourceNamePattern.MatchString(rest) {
+		return strings.ToLower(scheme)
+	}
+	return rest
+}
 
-	// Check MLFLOW_TRACKING_URI env var
+// sourceURI resolves the source URI. --dir and the mlflow flags are
+// shorthands for the equivalent URIs.
+// Priority: --source > --dir > --mlflow-arn > --mlflow-url > CWL_SOURCE env >
+// MLFLOW_TRACKING_URI env > CloudWatch default.
+func sourceURI() string {
+	switch {
+	case len(sources) > 0:
+		return parseSources(sources)[0].uri
+	case localDir != "":
+		return "file://" + localDir
+	case mlflowARN != "":
+		return "mlflow+sagemaker://" + mlflowARN
+	case mlflowURL != "":
+		return "mlflow+" + mlflowURL
+	}
+	if uri := os.Getenv("CWL_SOURCE"); uri != "" {
+		return uri
+	}
 	if uri := os.Getenv("MLFLOW_TRACKING_URI"); uri != "" {
 		if strings.HasPrefix(uri, "arn:") {
-			return mlflow.NewFromSageMakerARN(uri, awsProfile)
+			return "mlflow+sagemaker://" + uri
 		}
-		return mlflow.New(uri), nil
+		return "mlflow+" + uri
 	}
-
-	// Default to CloudWatch
-	return cloudwatch.New(awsProfile)
+	return "cloudwatch://"
 }
 
 var rootCmd = &cobra.Command{
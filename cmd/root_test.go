@@ -0,0 +1,65 @@
+package cmd
+
+import "testing"
+
+// TestSourceURI verifies that the legacy backend flags and env vars map to
+// source URIs, and that --source and flags win over the environment.
+func TestSourceURI(t *testing.T) {
+	defer func() { sources, localDir, mlflowARN, mlflowURL = nil, "", "", "" }()
+	arn := "arn:aws:sagemaker:us-west-2:123456789012:mlflow-tracking-server/s"
+	cases := []struct {
+		name                   string
+		src                    []string
+		dir, mlArn, mlURL      string
+		cwlSource, trackingURI string
+		want                   string
+	}{
+		{name: "default", want: "cloudwatch://"},
+		{name: "source", src: []string{"dev=cloudwatch://dev@us-east-1"}, dir: "./d", cwlSource: "file:///x", want: "cloudwatch://dev@us-east-1"},
+		{name: "dir", dir: "./dump", cwlSource: "file:///x", want: "file://./dump"},
+		{name: "mlflow arn", mlArn: arn, mlURL: "http://h", want: "mlflow+sagemaker://" + arn},
+		{name: "mlflow url", mlURL: "http://h:5000", want: "mlflow+http://h:5000"},
+		{name: "env", cwlSource: "file:///x", trackingURI: "http://h", want: "file:///x"},
+		{name: "tracking url", trackingURI: "https://h", want: "mlflow+https://h"},
+		{name: "tracking arn", trackingURI: arn, want: "mlflow+sagemaker://" + arn},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sources, localDir, mlflowARN, mlflowURL = tc.src, tc.dir, tc.mlArn, tc.mlURL
+			t.Setenv("CWL_SOURCE", tc.cwlSource)
+			t.Setenv("MLFLOW_TRACKING_URI", tc.trackingURI)
+			if got := sourceURI(); got != tc.want {
+				t.Errorf("sourceURI() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestParseSources verifies that repeated --source values are named by a
+// name= prefix or after their URI, with duplicates numbered.
+func TestParseSources(t *testing.T) {
+	got := parseSources([]string{
+		"prod=cloudwatch://prod@us-east-1",
+		"cloudwatch://staging@us-west-2",
+		"loki+https://u:p@logs.example.com:3100?label=app",
+		"mlflow+sagemaker://arn:aws:sagemaker:us-west-2:1:mlflow-tracking-server/s",
+		"file:///var/log/a",
+		"file:///var/log/b",
+	})
+	want := []namedSource{
+		{"prod", "cloudwatch://prod@us-east-1"},
+		{"staging@us-west-2", "cloudwatch://staging@us-west-2"},
+		{"logs.example.com", "loki+https://u:p@logs.example.com:3100?label=app"},
+		{"mlflow+sagemaker", "mlflow+sagemaker://arn:aws:sagemaker:us-west-2:1:mlflow-tracking-server/s"},
+		{"file", "file:///var/log/a"},
+		{"file-2", "file:///var/log/b"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d sources, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("source %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/derricw/cwl/fetch"
+	"github.com/derricw/cwl/interfaces"
+	"github.com/spf13/cobra"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+var runGroup string
+var runStream string
+var splitOutput bool
+
+func init() {
+	runCmd.Flags().StringVarP(&runGroup, "group", "g", "", "Log group to write to")
+	runCmd.Flags().StringVarP(&runStream, "stream", "s", "", "Log stream to write to")
+	runCmd.Flags().BoolVar(&splitOutput, "split", false, "Write stdout and stderr to separate <stream>/stdout and <stream>/stderr streams")
+	runCmd.Flags().BoolVar(&createGroup, "create-group", false, "Create the log group if it does not exist")
+	runCmd.Flags().DurationVar(&flushInterval, "flush-interval", defaultFlushTime, "Maximum time to buffer events before uploading a partial batch")
+	addGroupOptionFlags(runCmd)
+	runCmd.MarkFlagRequired("group")
+	runCmd.MarkFlagRequired("stream")
+	rootCmd.AddCommand(runCmd)
+}
+
+// runSummary is the final event written after the child process exits.
+type runSummary struct {
+	Event      string   `json:"event"`
+	Command    []string `json:"command"`
+	ExitCode   int      `json:"exitCode"`
+	DurationMs int64    `json:"durationMs"`
+	Start      string   `json:"start"`
+	End        string   `json:"end"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// runOutput describes where one of the child's output pipes goes: the
+// terminal writer it is teed to, the log stream it is uploaded to, and an
+// optional tag prefixed to each uploaded line.
+type runOutput struct {
+	name     string
+	terminal io.Writer
+	stream   string
+	tag      string
+}
+
+// runAndShip runs argv, teeing its stdout and stderr to the terminal and
+// uploading each line to CloudWatch through the same batching path as
+// `cwl put`. When split is false both pipes go to a single stream and
+// stderr lines are tagged with "[stderr] ". A runSummary event is written
+// to the main stream (the stdout stream when split) after the child exits.
+// Returns the child's exit code, and any error reading its output or
+// uploading it.
+func runAndShip(ctx context.Context, client interfaces.CloudWatchLogsClient, groupName, streamName string, split bool, argv []string, stdout, stderr io.Writer) (int, error) {
+	outputs := []runOutput{
+		{name: "stdout", terminal: stdout, stream: streamName},
+		{name: "stderr", terminal: stderr, stream: streamName, tag: "[stderr] "},
+	}
+	if split {
+		outputs[0] = runOutput{name: "stdout", terminal: stdout, stream: streamName + "/stdout"}
+		outputs[1] = runOutput{name: "stderr", terminal: stderr, stream: streamName + "/stderr"}
+	}
+
+	// one uploader per destination stream
+	channels := map[string]chan types.InputLogEvent{}
+	uploadErrs := make(chan error, len(outputs))
+	var uploaders sync.WaitGroup
+	for _, o := range outputs {
+		if _, ok := channels[o.stream]; ok {
+			continue
+		}
+		if err := ensureLogStreamExists(client, groupName, o.stream); err != nil {
+			return 1, err
+		}
+		ch := make(chan types.InputLogEvent, maxBatchEvents)
+		channels[o.stream] = ch
+		uploaders.Add(1)
+		go func(stream string) {
+			defer uploaders.Done()
+			if err := putEvents(ctx, client, groupName, stream, ch, flushInterval); err != nil {
+				uploadErrs <- err
+				// keep draining so the child never blocks on a full channel
+				for range ch {
+				}
+			}
+		}(o.stream)
+	}
+	closeAll := func() {
+		for _, ch := range channels {
+			close(ch)
+		}
+		uploaders.Wait()
+	}
+
+	child := exec.Command(argv[0], argv[1:]...)
+	child.Stdin = os.Stdin
+	pipes := make([]io.Reader, len(outputs))
+	var err error
+	if pipes[0], err = child.StdoutPipe(); err != nil {
+		closeAll()
+		return 1, err
+	}
+	if pipes[1], err = child.StderrPipe(); err != nil {
+		closeAll()
+		return 1, err
+	}
+
+	start := time.Now()
+	if err := child.Start(); err != nil {
+		closeAll()
+		return 127, err
+	}
+	stopSignals := forwardSignals(child.Process)
+	defer stopSignals()
+
+	// lines are read like `cwl put` reads stdin, so long ones are split
+	// rather than ending the upload
+	readErrs := make(chan error, len(outputs))
+	var readers sync.WaitGroup
+	for i, o := range outputs {
+		readers.Add(1)
+		go func(r io.Reader, o runOutput) {
+			defer readers.Done()
+			lines := make(chan types.InputLogEvent, maxBatchEvents)
+			var readErr error
+			go func() {
+				defer close(lines)
+				if readErr = readInput(io.TeeReader(r, o.terminal), nil, lines); readErr != nil {
+					// keep the child from blocking on a full pipe
+					io.Copy(o.terminal, r)
+				}
+			}()
+			for e := range aggregateLines(lines, nil, 0, defaultMaxMessageSize-len(o.tag)) {
+				e.Message = aws.String(o.tag + aws.ToString(e.Message))
+				channels[o.stream] <- e
+			}
+			if readErr != nil {
+				readErrs <- fmt.Errorf("reading %s: %w", o.name, readErr)
+			}
+		}(pipes[i], o)
+	}
+	readers.Wait()
+	close(readErrs)
+
+	waitErr := child.Wait()
+	end := time.Now()
+	exitCode := 0
+	summary := runSummary{
+		Event:      "cwl.run.exit",
+		Command:    argv,
+		DurationMs: end.Sub(start).Milliseconds(),
+		Start:      start.UTC().Format(time.RFC3339Nano),
+		End:        end.UTC().Format(time.RFC3339Nano),
+	}
+	if waitErr != nil {
+		var exitErr *exec.ExitError
+		if errors.As(waitErr, &exitErr) {
+			exitCode = exitStatus(exitErr)
+		} else {
+			exitCode = 1
+		}
+		summary.Error = waitErr.Error()
+	}
+	summary.ExitCode = exitCode
+	if data, err := json.Marshal(summary); err == nil {
+		channels[outputs[0].stream] <- types.InputLogEvent{
+			Message:   aws.String(string(data)),
+			Timestamp: aws.Int64(end.UnixMilli()),
+		}
+	}
+
+	closeAll()
+	close(uploadErrs)
+	var shipErr error
+	for err := range readErrs {
+		shipErr = errors.Join(shipErr, err)
+	}
+	for err := range uploadErrs {
+		shipErr = errors.Join(shipErr, err)
+	}
+	return exitCode, shipErr
+}
+
+// exitStatus returns the child's exit code, or 128+signal when a signal
+// killed it, as shells report it. ExitCode alone gives -1 for a signal.
+func exitStatus(err *exec.ExitError) int {
+	if status, ok := err.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return err.ExitCode()
+}
+
+// forwardSignals passes SIGINT and SIGTERM on to the child so that Ctrl-C
+// stops the wrapped command while cwl keeps running long enough to flush
+// its output and write the summary event.
+func forwardSignals(p *os.Process) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				p.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run --group G --stream S -- command [args...]",
+	Short: "run a command and ship its output to CloudWatch",
+	Long: `Runs a command, teeing its stdout and stderr to the terminal and to a log
+stream. Output is uploaded in the background in batches, like cwl put.
+When the command exits, a final JSON event with the exit code, duration and
+command line is written, and cwl exits with the command's exit code. If the
+command succeeds but its output could not be read or uploaded, cwl reports
+the error and exits 1.`,
+	Example: `
+    cwl run --group /batch/nightly --stream $(date +%F) -- ./nightly.sh --full
+
+Write stdout and stderr to separate streams:
+
+    cwl run -g /batch/nightly -s run-42 --split -- python train.py
+  `,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := groupOptionsFromFlags()
+		if err := opts.validate(); err != nil {
+			log.Fatal(err)
+		}
+		client, err := fetch.CreateClient(awsProfile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := ensureLogGroupExists(context.TODO(), client, runGroup, createGroup, opts); err != nil {
+			log.Fatal(err)
+		}
+
+		exitCode, err := runAndShip(context.Background(), client, runGroup, runStream, splitOutput, args, os.Stdout, os.Stderr)
+		if err != nil {
+			// a failing child's code wins; otherwise lost output is a failure
+			fmt.Fprintf(os.Stderr, "cwl run: %s: %v\n", strings.Join(args, " "), err)
+			if exitCode == 0 {
+				exitCode = 1
+			}
+		}
+		os.Exit(exitCode)
+	},
+}
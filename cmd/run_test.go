@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// eventsByStream flattens recorded batches into messages per stream.
+func eventsByStream(client *mockPutClient) map[string][]string {
+	result := map[string][]string{}
+	for i, batch := range client.batches {
+		for _, e := range batch {
+			result[client.batchDest[i]] = append(result[client.batchDest[i]], *e.Message)
+		}
+	}
+	return result
+}
+
+// TestRunAndShipTaggedLines verifies that stdout and stderr are teed to the
+// terminal and uploaded to one stream with stderr lines tagged, followed by
+// a summary event, and that the child's exit code is returned.
+func TestRunAndShipTaggedLines(t *testing.T) {
+	client := &mockPutClient{}
+	var stdout, stderr bytes.Buffer
+	argv := []string{"sh", "-c", "echo out; echo err >&2; exit 3"}
+
+	code, err := runAndShip(context.Background(), client, "group", "run", false, argv, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != 3 {
+		t.Fatalf("expected exit code 3, got %d", code)
+	}
+	if stdout.String() != "out\n" || stderr.String() != "err\n" {
+		t.Fatalf("expected output teed to terminal, got stdout=%q stderr=%q", stdout.String(), stderr.String())
+	}
+
+	messages := eventsByStream(client)["run"]
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 events, got %v", messages)
+	}
+	joined := strings.Join(messages, "\n")
+	if !strings.Contains(joined, "out") || !strings.Contains(joined, "[stderr] err") {
+		t.Fatalf("expected tagged stdout/stderr lines, got %v", messages)
+	}
+
+	var summary runSummary
+	if err := json.Unmarshal([]byte(messages[len(messages)-1]), &summary); err != nil {
+		t.Fatalf("expected final event to be JSON: %v", err)
+	}
+	if summary.ExitCode != 3 || summary.Command[0] != "sh" {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+}
+
+// TestRunAndShipLongLine verifies that a line longer than one event is
+// split and that the output after it is still uploaded.
+func TestRunAndShipLongLine(t *testing.T) {
+	client := &mockPutClient{}
+	var stdout, stderr bytes.Buffer
+	argv := []string{"sh", "-c", "head -c 300000 /dev/zero | tr '\\0' x; echo; echo after"}
+
+	code, err := runAndShip(context.Background(), client, "group", "run", false, argv, &stdout, &stderr)
+	if err != nil || code != 0 {
+		t.Fatalf("got code %d, err %v", code, err)
+	}
+	if stdout.Len() != 300000+len("\nafter\n") {
+		t.Errorf("terminal got %d bytes", stdout.Len())
+	}
+	messages := eventsByStream(client)["run"]
+	if len(messages) != 4 {
+		t.Fatalf("expected 2 parts, after and the summary, got %d events", len(messages))
+	}
+	if len(messages[0])+len(messages[1]) != 300000 || len(messages[0]) > defaultMaxMessageSize {
+		t.Errorf("long line split into %d and %d bytes", len(messages[0]), len(messages[1]))
+	}
+	if messages[2] != "after" {
+		t.Errorf("expected the next line to be uploaded, got %q", messages[2])
+	}
+}
+
+// TestRunAndShipSplit verifies that --split sends stdout and stderr to
+// separate streams and puts the summary on the stdout stream.
+func TestRunAndShipSplit(t *testing.T) {
+	client := &mockPutClient{}
+	var stdout, stderr bytes.Buffer
+	argv := []string{"sh", "-c", "echo out; echo err >&2"}
+
+	code, err := runAndShip(context.Background(), client, "group", "run", true, argv, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	streams := eventsByStream(client)
+	if got := streams["run/stderr"]; len(got) != 1 || got[0] != "err" {
+		t.Fatalf("expected untagged stderr line on stderr stream, got %v", got)
+	}
+	if got := streams["run/stdout"]; len(got) != 2 || got[0] != "out" {
+		t.Fatalf("expected stdout line and summary on stdout stream, got %v", got)
+	}
+}
+
+// TestRunAndShipMissingCommand verifies that a command that cannot be
+// started returns 127, like a shell would.
+func TestRunAndShipMissingCommand(t *testing.T) {
+	client := &mockPutClient{}
+	var stdout, stderr bytes.Buffer
+	code, err := runAndShip(context.Background(), client, "group", "run", false, []string{"/nonexistent/cmd"}, &stdout, &stderr)
+	if err == nil {
+		t.Fatal("expected error for missing command")
+	}
+	if code != 127 {
+		t.Fatalf("expected exit code 127, got %d", code)
+	}
+}
+
+// TestRunAndShipKilledBySignal verifies that a child killed by a signal
+// exits, and is summarised, as 128+signal like a shell reports it.
+func TestRunAndShipKilledBySignal(t *testing.T) {
+	client := &mockPutClient{}
+	var stdout, stderr bytes.Buffer
+	argv := []string{"sh", "-c", "kill -TERM $$"}
+
+	code, err := runAndShip(context.Background(), client, "group", "run", false, argv, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if code != 143 {
+		t.Fatalf("expected exit code 143, got %d", code)
+	}
+	messages := eventsByStream(client)["run"]
+	var summary runSummary
+	if err := json.Unmarshal([]byte(messages[len(messages)-1]), &summary); err != nil {
+		t.Fatalf("expected final event to be JSON: %v", err)
+	}
+	if summary.ExitCode != 143 {
+		t.Fatalf("expected summary exit code 143, got %d", summary.ExitCode)
+	}
+}
@@ -8,10 +8,15 @@ import (
 	"io"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
+	"github.com/derricw/cwl/arn"
 	"github.com/derricw/cwl/fetch"
+	"github.com/derricw/cwl/interfaces"
+	"github.com/derricw/cwl/provider"
 	"github.com/spf13/cobra"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -20,11 +25,19 @@ import (
 )
 
 var prefix string
+var activeSince string
+var inactiveFor string
+var streamLimit int
 
 func init() {
 	streamsCmd.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "Output full json")
 	streamsCmd.PersistentFlags().BoolVarP(&follow, "follow", "f", false, "Keep checking for new streams with events")
 	streamsCmd.PersistentFlags().StringVarP(&prefix, "prefix", "P", "", "Filter streams by prefix")
+	streamsCmd.PersistentFlags().BoolVarP(&longOutput, "long", "l", false, "Output a table with first/last event times, stored bytes and age")
+	streamsCmd.PersistentFlags().StringVar(&activeSince, "active-since", "", "Only list streams with an event in this window (e.g. 10m, 1h, 2d)")
+	streamsCmd.PersistentFlags().StringVar(&inactiveFor, "inactive-for", "", "Only list streams with no event in this window (e.g. 7d)")
+	streamsCmd.PersistentFlags().IntVarP(&streamLimit, "limit", "n", 0, "List at most this many streams per group (0 = no limit)")
+	streamsCmd.PersistentFlags().StringArrayVar(&tagSelectors, "tag", nil, "List streams of the log groups with this tag, as key=value or just key (repeatable)")
 	rootCmd.AddCommand(streamsCmd)
 }
 
@@ -41,13 +54,134 @@ func writeStream(w io.Writer, stream types.LogStream) {
 	}
 }
 
+// writeStreamRow writes one --long row to a tabwriter.
+func writeStreamRow(w io.Writer, groupName string, stream types.LogStream, now time.Time) {
+	formatTime := func(ms *int64) string {
+		if ms == nil {
+			return "-"
+		}
+		return time.UnixMilli(*ms).UTC().Format(time.RFC3339)
+	}
+	age := "-"
+	if stream.CreationTime != nil {
+		age = formatAge(now.Sub(time.UnixMilli(*stream.CreationTime)))
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", groupName, aws.ToString(stream.LogStreamName),
+		formatTime(stream.FirstEventTimestamp), formatTime(stream.LastEventTimestamp),
+		provider.FormatBytes(aws.ToInt64(stream.StoredBytes)), age)
+}
+
+// parseAge parses a duration that, unlike time.ParseDuration, also accepts
+// days and weeks (e.g. "7d", "2w"), which is how retention-style windows are
+// usually written.
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if number, ok := strings.CutSuffix(s, suffix); ok {
+			n, err := strconv.ParseFloat(number, 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(n * float64(unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q: expected e.g. 30s, 10m, 1h, 7d or 2w", s)
+	}
+	return d, nil
+}
+
+// formatAge renders d in its largest whole unit, e.g. "3d" or "5h".
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	default:
+		return fmt.Sprintf("%ds", int(d/time.Second))
+	}
+}
+
+// streamFilters select streams by LastEventTimestamp. Zero durations are
+// unset.
+type streamFilters struct {
+	activeSince time.Duration
+	inactiveFor time.Duration
+	now         time.Time
+}
+
+func (f streamFilters) lastEvent(stream types.LogStream) time.Time {
+	if stream.LastEventTimestamp == nil {
+		return time.Time{} // never had an event
+	}
+	return time.UnixMilli(*stream.LastEventTimestamp)
+}
+
+func (f streamFilters) match(stream types.LogStream) bool {
+	last := f.lastEvent(stream)
+	if f.activeSince > 0 && last.Before(f.now.Add(-f.activeSince)) {
+		return false
+	}
+	if f.inactiveFor > 0 && !last.Before(f.now.Add(-f.inactiveFor)) {
+		return false
+	}
+	return true
+}
+
+// pastCutoff reports whether no stream after this one can match
+// --active-since, given streams ordered by last event time, newest first.
+func (f streamFilters) pastCutoff(stream types.LogStream) bool {
+	return f.activeSince > 0 && f.lastEvent(stream).Before(f.now.Add(-f.activeSince))
+}
+
 var streamsCmd = &cobra.Command{
 	Use:   "streams [group]",
 	Short: "List stream arns for a log group",
-	Long:  `Lists all available streams for a log group.`,
-	Args:  cobra.MatchAll(cobra.MaximumNArgs(1)),
+	Long: `Lists all available streams for a log group.
+
+--active-since and --inactive-for filter on each stream's last event time.
+Streams are listed newest first, so --active-since stops paginating as soon
+as it reaches an older stream, which keeps it cheap on groups with many
+streams. CloudWatch updates the last event time eventually rather than
+immediately, so allow some slack in short windows.`,
+	Example: `
+    cwl streams /my/app --long --limit 20
+    cwl streams /my/app --active-since 10m | cwl events -f
+    cwl streams /my/app --inactive-for 30d
+  `,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(tagSelectors) > 0 && len(args) > 0 {
+			return fmt.Errorf("pass either a group or --tag, not both")
+		}
+		if longOutput && jsonOutput {
+			return fmt.Errorf("--long and --json are mutually exclusive")
+		}
+		if streamLimit < 0 {
+			return fmt.Errorf("--limit must not be negative")
+		}
+		if streamLimit > 0 && follow {
+			return fmt.Errorf("--limit can't be combined with --follow")
+		}
+		return cobra.MaximumNArgs(1)(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 
+		filters := streamFilters{now: time.Now()}
+		var err error
+		if activeSince != "" {
+			if filters.activeSince, err = parseAge(activeSince); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if inactiveFor != "" {
+			if filters.inactiveFor, err = parseAge(inactiveFor); err != nil {
+				log.Fatal(err)
+			}
+		}
+
 		client, err := fetch.CreateClient(awsProfile)
 		if err != nil {
 			log.Fatal(err)
@@ -55,7 +189,13 @@ var streamsCmd = &cobra.Command{
 
 		var readFrom io.Reader
 
-		if len(args) == 0 {
+		if len(tagSelectors) > 0 {
+			names, err := selectGroupsByTag(context.TODO(), client)
+			if err != nil {
+				log.Fatal(err)
+			}
+			readFrom = strings.NewReader(strings.Join(names, "\n"))
+		} else if len(args) == 0 {
 			// read groups from stdin
 			readFrom = os.Stdin
 		} else {
@@ -64,53 +204,95 @@ var streamsCmd = &cobra.Command{
 		}
 
 		seen := map[string]struct{}{}
+		seenGroups := map[string]struct{}{}
 
-		w := bufio.NewWriter(os.Stdout)
+		var w interface {
+			io.Writer
+			Flush() error
+		} = bufio.NewWriter(os.Stdout)
+		if longOutput {
+			w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "GROUP\tSTREAM\tFIRST EVENT\tLAST EVENT\tSTORED\tAGE")
+		}
 		defer w.Flush()
 
 		scanner := bufio.NewScanner(readFrom)
+		var parser arn.Parser
 		for scanner.Scan() {
-			groupName := scanner.Text()
-			var nextToken *string
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			// accept any cwl output (ARNs, JSON, tab-separated listings,
+			// --long tables) as well as bare group names
+			groupId, ok, err := parser.Parse(scanner.Text())
+			if err != nil {
+				log.Printf("skipping input: %v", err)
+				continue
+			}
+			if !ok {
+				continue // a --long table's header
+			}
+			groupName := groupId.GroupName
+			if _, found := seenGroups[groupName]; found {
+				continue // e.g. several stream ARNs from the same group
+			}
+			seenGroups[groupName] = struct{}{}
 			start := time.Now().UnixNano() / 1000000
+			listed := 0
 			for {
-				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-				input := &cloudwatchlogs.DescribeLogStreamsInput{
-					LogGroupName: &groupName,
-					Limit:        aws.Int32(50),
-					OrderBy:      types.OrderByLastEventTime,
-					Descending:   aws.Bool(true),
-					NextToken:    nextToken,
-				}
-				if prefix != "" {
-					input.LogStreamNamePrefix = &prefix
-					input.OrderBy = types.OrderByLogStreamName
-				}
-				output, err := client.DescribeLogStreams(ctx, input)
-				if err != nil {
-					log.Fatal(err)
-				}
-				cancel()
-				for _, stream := range output.LogStreams {
+				err := scanStreams(client, groupName, filters, func(stream types.LogStream) bool {
 					if !follow || stream.LastIngestionTime == nil || *stream.LastIngestionTime > start {
 						if _, found := seen[*stream.LogStreamName]; !found {
-							writeStream(w, stream)
+							if longOutput {
+								writeStreamRow(w, groupName, stream, filters.now)
+							} else {
+								writeStream(w, stream)
+							}
 							seen[*stream.LogStreamName] = struct{}{}
+							listed++
 						}
 					}
+					return streamLimit == 0 || listed < streamLimit
+				})
+				if err != nil {
+					log.Fatal(err)
 				}
-				if output.NextToken != nil {
-					nextToken = output.NextToken
-				} else {
-					if follow {
-						w.Flush()
-						time.Sleep(time.Second * 10)
-						nextToken = nil
-					} else {
-						break
-					}
+				if !follow {
+					break
 				}
+				w.Flush()
+				time.Sleep(time.Second * 10)
+				filters.now = time.Now()
 			}
 		}
 	},
 }
+
+// scanStreams pages through a group's streams, newest first (or by name
+// with --prefix), calling emit for each one that passes the filters until
+// emit returns false. With --active-since, paging stops at the first stream
+// older than the window, since every later stream is older still.
+func scanStreams(client interfaces.CloudWatchLogsClient, groupName string, filters streamFilters, emit func(types.LogStream) bool) error {
+	input := &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName: &groupName,
+		Limit:        aws.Int32(50),
+		OrderBy:      types.OrderByLastEventTime,
+		Descending:   aws.Bool(true),
+	}
+	if prefix != "" {
+		input.LogStreamNamePrefix = &prefix
+		input.OrderBy = types.OrderByLogStreamName
+	}
+	for stream, err := range fetch.LogStreams(context.Background(), client, input, fetch.PageOptions{PageTimeout: 30 * time.Second}) {
+		if err != nil {
+			return err
+		}
+		if input.OrderBy == types.OrderByLastEventTime && filters.pastCutoff(stream) {
+			return nil
+		}
+		if filters.match(stream) && !emit(stream) {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func streamWithLastEvent(name string, last time.Time) types.LogStream {
+	return types.LogStream{LogStreamName: aws.String(name), LastEventTimestamp: aws.Int64(last.UnixMilli())}
+}
+
+func TestParseAge(t *testing.T) {
+	cases := map[string]time.Duration{
+		"10m":  10 * time.Minute,
+		"1h":   time.Hour,
+		"7d":   7 * 24 * time.Hour,
+		"2w":   14 * 24 * time.Hour,
+		"1.5d": 36 * time.Hour,
+	}
+	for in, want := range cases {
+		got, err := parseAge(in)
+		if err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "d", "-1h", "yesterday"} {
+		if _, err := parseAge(in); err == nil {
+			t.Errorf("expected error for %q", in)
+		}
+	}
+}
+
+// TestScanStreamsActiveSince verifies that --active-since stops paginating
+// at the first stream older than the window instead of reading every page.
+func TestScanStreamsActiveSince(t *testing.T) {
+	now := time.Now()
+	client := &mockPutClient{streamPages: [][]types.LogStream{
+		{streamWithLastEvent("a", now.Add(-time.Minute)), streamWithLastEvent("b", now.Add(-5*time.Minute))},
+		{streamWithLastEvent("c", now.Add(-time.Hour)), streamWithLastEvent("d", now.Add(-2*time.Hour))},
+		{streamWithLastEvent("e", now.Add(-48*time.Hour))},
+	}}
+	var got []string
+	filters := streamFilters{activeSince: 10 * time.Minute, now: now}
+	err := scanStreams(client, "group", filters, func(s types.LogStream) bool {
+		got = append(got, *s.LogStreamName)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "a,b" {
+		t.Fatalf("expected [a b], got %v", got)
+	}
+	if client.streamCalls != 2 {
+		t.Fatalf("expected paging to stop after 2 pages, got %d calls", client.streamCalls)
+	}
+}
+
+// TestScanStreamsInactiveForAndLimit verifies the --inactive-for filter, and
+// that returning false from emit (as --limit does) stops the scan.
+func TestScanStreamsInactiveForAndLimit(t *testing.T) {
+	now := time.Now()
+	client := &mockPutClient{streamPages: [][]types.LogStream{
+		{streamWithLastEvent("fresh", now.Add(-time.Hour)), streamWithLastEvent("stale", now.Add(-10*24*time.Hour))},
+		{{LogStreamName: aws.String("empty")}, streamWithLastEvent("older", now.Add(-30*24*time.Hour))},
+	}}
+	var got []string
+	filters := streamFilters{inactiveFor: 7 * 24 * time.Hour, now: now}
+	err := scanStreams(client, "group", filters, func(s types.LogStream) bool {
+		got = append(got, *s.LogStreamName)
+		return len(got) < 2
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "stale,empty" {
+		t.Fatalf("expected [stale empty], got %v", got)
+	}
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/derricw/cwl/fetch"
+	"github.com/derricw/cwl/interfaces"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// tagLookupConcurrency bounds the number of ListTagsForResource calls in
+// flight. The API has a low per-account rate limit, so this stays small.
+const tagLookupConcurrency = 8
+
+// tagSelectors holds --tag values for every command that selects groups.
+var tagSelectors []string
+
+// tagSelector matches resources carrying a tag. An empty value (from
+// "--tag team") matches any value of the key.
+type tagSelector struct {
+	key   string
+	value string
+}
+
+// parseTagSelectors parses "key=value" or "key" selectors.
+func parseTagSelectors(specs []string) ([]tagSelector, error) {
+	selectors := make([]tagSelector, len(specs))
+	for i, spec := range specs {
+		key, value, _ := strings.Cut(spec, "=")
+		if key == "" {
+			return nil, fmt.Errorf("invalid tag %q: expected key=value or key", spec)
+		}
+		selectors[i] = tagSelector{key: key, value: value}
+	}
+	return selectors, nil
+}
+
+// parseTags parses "key=value" pairs for tagging a resource.
+func parseTags(specs []string) (map[string]string, error) {
+	tags := map[string]string{}
+	for _, spec := range specs {
+		key, value, ok := strings.Cut(spec, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid tag %q: expected key=value", spec)
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+// matchTags reports whether tags satisfy every selector.
+func matchTags(tags map[string]string, selectors []tagSelector) bool {
+	for _, s := range selectors {
+		value, ok := tags[s.key]
+		if !ok || (s.value != "" && value != s.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// groupArn returns the ARN that tagging APIs expect: the log group ARN
+// without the trailing ":*" that LogGroup.Arn carries.
+func groupArn(group types.LogGroup) string {
+	if group.LogGroupArn != nil {
+		return *group.LogGroupArn
+	}
+	return strings.TrimSuffix(aws.ToString(group.Arn), ":*")
+}
+
+// tagCache remembers the tags of each resource, so a group is only looked
+// up once however many times it is filtered (e.g. across pages or while
+// following).
+type tagCache struct {
+	client interfaces.CloudWatchLogsClient
+	mu     sync.Mutex
+	tags   map[string]map[string]string
+}
+
+func newTagCache(client interfaces.CloudWatchLogsClient) *tagCache {
+	return &tagCache{client: client, tags: map[string]map[string]string{}}
+}
+
+func (c *tagCache) get(ctx context.Context, arn string) (map[string]string, error) {
+	c.mu.Lock()
+	tags, ok := c.tags[arn]
+	c.mu.Unlock()
+	if ok {
+		return tags, nil
+	}
+	output, err := c.client.ListTagsForResource(ctx, &cloudwatchlogs.ListTagsForResourceInput{
+		ResourceArn: aws.String(arn),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing tags of %s: %w", arn, err)
+	}
+	c.mu.Lock()
+	c.tags[arn] = output.Tags
+	c.mu.Unlock()
+	return output.Tags, nil
+}
+
+// filter returns the groups whose tags match every selector, in their
+// original order. Tags are looked up concurrently.
+func (c *tagCache) filter(ctx context.Context, groups []types.LogGroup, selectors []tagSelector) ([]types.LogGroup, error) {
+	if len(selectors) == 0 {
+		return groups, nil
+	}
+	matched := make([]bool, len(groups))
+	errs := make([]error, len(groups))
+	sem := make(chan struct{}, tagLookupConcurrency)
+	var wg sync.WaitGroup
+	for i, group := range groups {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			tags, err := c.get(ctx, groupArn(group))
+			errs[i] = err
+			matched[i] = err == nil && matchTags(tags, selectors)
+		}()
+	}
+	wg.Wait()
+
+	var result []types.LogGroup
+	for i, group := range groups {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if matched[i] {
+			result = append(result, group)
+		}
+	}
+	return result, nil
+}
+
+// groupNamesByTag lists every log group matching the selectors, for
+// commands that accept --tag in place of group names.
+func groupNamesByTag(ctx context.Context, client interfaces.CloudWatchLogsClient, selectors []tagSelector) ([]string, error) {
+	cache := newTagCache(client)
+	var names []string
+	for page, err := range fetch.LogGroupPages(ctx, client, &cloudwatchlogs.DescribeLogGroupsInput{}, fetch.PageOptions{}) {
+		if err != nil {
+			return nil, err
+		}
+		groups, err := cache.filter(ctx, page, selectors)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			names = append(names, aws.ToString(group.LogGroupName))
+		}
+	}
+	return names, nil
+}
+
+// selectGroupsByTag resolves the --tag flag to group names for commands that
+// otherwise take groups as arguments. It fails when nothing matches, since
+// silently falling back to "all groups" would be surprising.
+func selectGroupsByTag(ctx context.Context, client interfaces.CloudWatchLogsClient) ([]string, error) {
+	selectors, err := parseTagSelectors(tagSelectors)
+	if err != nil {
+		return nil, err
+	}
+	names, err := groupNamesByTag(ctx, client, selectors)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no log groups match --tag %s", strings.Join(tagSelectors, " --tag "))
+	}
+	return names, nil
+}
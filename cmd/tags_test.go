@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func taggedGroup(name string) types.LogGroup {
+	return types.LogGroup{
+		LogGroupName: aws.String(name),
+		Arn:          aws.String("arn:aws:logs:us-west-2:123:log-group:" + name + ":*"),
+	}
+}
+
+// TestTagFilter verifies that every selector must match, that a bare key
+// matches any value, and that each group's tags are only fetched once.
+func TestTagFilter(t *testing.T) {
+	groups := []types.LogGroup{taggedGroup("a"), taggedGroup("b"), taggedGroup("c")}
+	client := &mockPutClient{tags: map[string]map[string]string{
+		"arn:aws:logs:us-west-2:123:log-group:a": {"team": "payments", "env": "prod"},
+		"arn:aws:logs:us-west-2:123:log-group:b": {"team": "payments"},
+		"arn:aws:logs:us-west-2:123:log-group:c": {"team": "search", "env": "prod"},
+	}}
+	cache := newTagCache(client)
+
+	selectors, err := parseTagSelectors([]string{"team=payments", "env"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := cache.filter(context.Background(), groups, selectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || *got[0].LogGroupName != "a" {
+		t.Fatalf("expected only group a, got %v", got)
+	}
+
+	if _, err := cache.filter(context.Background(), groups, selectors[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if client.tagLookups != 3 {
+		t.Fatalf("expected 3 tag lookups with caching, got %d", client.tagLookups)
+	}
+}
+
+func TestGroupNamesByTag(t *testing.T) {
+	client := &mockPutClient{
+		groupPages: [][]types.LogGroup{{taggedGroup("a")}, {taggedGroup("b")}},
+		tags: map[string]map[string]string{
+			"arn:aws:logs:us-west-2:123:log-group:b": {"team": "payments"},
+		},
+	}
+	names, err := groupNamesByTag(context.Background(), client, []tagSelector{{key: "team", value: "payments"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "b" {
+		t.Fatalf("expected [b], got %v", names)
+	}
+}
+
+// TestEditGroupTags verifies that tags are set and removed on the group's
+// ARN and the resulting tags are printed sorted.
+func TestEditGroupTags(t *testing.T) {
+	client := &mockPutClient{
+		groupPages: [][]types.LogGroup{{taggedGroup("/my/app")}},
+		tags: map[string]map[string]string{
+			"arn:aws:logs:us-west-2:123:log-group:/my/app": {"owner": "bob"},
+		},
+	}
+	tags, err := editGroupTags(context.Background(), client, "/my/app",
+		map[string]string{"team": "payments", "env": "prod"}, []string{"owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := writeTags(&out, tags); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "env=prod\nteam=payments\n" {
+		t.Fatalf("unexpected tags %q", out.String())
+	}
+
+	if _, err := editGroupTags(context.Background(), client, "/missing", nil, nil); err == nil {
+		t.Fatal("expected error for a missing group")
+	}
+	if _, err := parseTagSelectors([]string{"=x"}); err == nil {
+		t.Fatal("expected error for an empty tag key")
+	}
+}
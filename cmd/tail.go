@@ -0,0 +1,399 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/derricw/cwl/interfaces"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+const defaultStreamTemplate = "{hostname}/{filename}"
+
+// tailPollInterval is how often followed files are checked for new lines.
+var tailPollInterval = time.Second
+
+// maxReadPerPoll caps how much of a file is read in one go, so catching up
+// on a large file doesn't hold it all in memory.
+const maxReadPerPoll = 4 * maxBatchBytes
+
+// fileState is the persisted read position of a shipped file. The inode is
+// kept so a file that was rotated while cwl was stopped starts from 0
+// instead of resuming at a stale offset.
+type fileState struct {
+	Inode  uint64 `json:"inode"`
+	Offset int64  `json:"offset"`
+}
+
+// shipState maps file paths to read positions. It is saved after every
+// successful upload, so restarts neither duplicate nor drop lines.
+type shipState struct {
+	path  string
+	Files map[string]fileState `json:"files"`
+}
+
+// loadShipState reads the state file at path. An empty path keeps state in
+// memory only, and a missing file starts fresh.
+func loadShipState(path string) (*shipState, error) {
+	s := &shipState{path: path, Files: map[string]fileState{}}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	if s.Files == nil {
+		s.Files = map[string]fileState{}
+	}
+	return s, nil
+}
+
+// save writes the state atomically (write then rename) so a crash mid-write
+// never leaves a corrupt state file behind.
+func (s *shipState) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, creating parent directories as needed.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// tailedFile is an open file being shipped, read from offset onward.
+type tailedFile struct {
+	path      string
+	stream    string
+	f         *os.File
+	info      os.FileInfo
+	offset    int64
+	extractor *timestampExtractor
+}
+
+// openTailedFile opens path and resumes from the saved offset when the
+// saved inode still matches the file on disk.
+func openTailedFile(path string, saved fileState) (*tailedFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	t := &tailedFile{path: path, f: f, info: info}
+	if saved.Inode == fileID(info) && saved.Offset <= info.Size() {
+		t.offset = saved.Offset
+	}
+	return t, nil
+}
+
+func (t *tailedFile) state() fileState {
+	return fileState{Inode: fileID(t.info), Offset: t.offset}
+}
+
+// readLines returns complete lines appended since the last read. A trailing
+// partial line is left for the next call unless final is set (used at EOF of
+// a one-shot read and when draining a rotated file). The offset only moves
+// past bytes that were returned.
+func (t *tailedFile) readLines(final bool) ([]string, error) {
+	var lines []string
+	var pending []byte
+	buf := make([]byte, 64*1024)
+	read := 0
+	for read < maxReadPerPoll {
+		n, err := t.f.ReadAt(buf, t.offset+int64(len(pending)))
+		read += n
+		pending = append(pending, buf[:n]...)
+		for {
+			i := bytes.IndexByte(pending, '\n')
+			if i < 0 {
+				break
+			}
+			line := strings.TrimSuffix(string(pending[:i]), "\r")
+			lines = append(lines, splitMessage(line, defaultMaxMessageSize)...)
+			pending = pending[i+1:]
+			t.offset += int64(i + 1)
+		}
+		if len(pending) >= defaultMaxMessageSize {
+			// a line this long will never fit in one event; ship the full
+			// event-sized parts and keep the rest waiting for more
+			parts := splitMessage(string(pending), defaultMaxMessageSize)
+			for _, part := range parts[:len(parts)-1] {
+				lines = append(lines, part)
+				t.offset += int64(len(part))
+				pending = pending[len(part):]
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return lines, err
+		}
+	}
+	if final && read < maxReadPerPoll && len(pending) > 0 {
+		lines = append(lines, string(pending)) // shorter than an event
+		t.offset += int64(len(pending))
+	}
+	return lines, nil
+}
+
+// rotation reports what happened to the path since the file was opened:
+// renamed/removed (a different file or none now lives at the path), or
+// truncated (same file, but smaller than what was already read).
+func (t *tailedFile) rotation() (replaced, truncated bool) {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		return true, false
+	}
+	if !os.SameFile(info, t.info) {
+		return true, false
+	}
+	t.info = info
+	return false, info.Size() < t.offset
+}
+
+// fileShipper tails files matching glob patterns and uploads their lines,
+// one log stream per file.
+type fileShipper struct {
+	client         interfaces.CloudWatchLogsClient
+	groupName      string
+	patterns       []string
+	streamTemplate string
+	hostname       string
+	state          *shipState
+	files          map[string]*tailedFile
+	streams        map[string]bool // streams known to exist
+	newExtractor   func() *timestampExtractor
+}
+
+func newFileShipper(client interfaces.CloudWatchLogsClient, groupName string, patterns []string, streamTemplate string, state *shipState) *fileShipper {
+	hostname, _ := os.Hostname()
+	if streamTemplate == "" {
+		streamTemplate = defaultStreamTemplate
+	}
+	return &fileShipper{
+		client:         client,
+		groupName:      groupName,
+		patterns:       patterns,
+		streamTemplate: streamTemplate,
+		hostname:       hostname,
+		state:          state,
+		files:          map[string]*tailedFile{},
+		streams:        map[string]bool{},
+		newExtractor:   func() *timestampExtractor { return nil },
+	}
+}
+
+// streamName expands {hostname}, {filename} and {path} in the template.
+func (s *fileShipper) streamName(path string) string {
+	return strings.NewReplacer(
+		"{hostname}", s.hostname,
+		"{filename}", filepath.Base(path),
+		"{path}", strings.TrimPrefix(filepath.ToSlash(path), "/"),
+	).Replace(s.streamTemplate)
+}
+
+// discover opens any files matching the patterns that aren't tailed yet.
+func (s *fileShipper) discover() error {
+	for _, pattern := range s.patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return err
+		}
+		sort.Strings(matches)
+		for _, path := range matches {
+			if _, ok := s.files[path]; ok {
+				continue
+			}
+			if err := s.open(path); err != nil {
+				log.Printf("skipping %s: %v", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *fileShipper) open(path string) error {
+	t, err := openTailedFile(path, s.state.Files[path])
+	if err != nil {
+		return err
+	}
+	t.stream = s.streamName(path)
+	t.extractor = s.newExtractor()
+	if !s.streams[t.stream] {
+		if err := ensureLogStreamExists(s.client, s.groupName, t.stream); err != nil {
+			t.f.Close()
+			return err
+		}
+		s.streams[t.stream] = true
+	}
+	s.files[path] = t
+	return nil
+}
+
+// ship uploads lines from t and then records its new offset.
+func (s *fileShipper) ship(ctx context.Context, t *tailedFile, lines []string) error {
+	if prev, ok := s.state.Files[t.path]; ok && len(lines) == 0 && prev == t.state() {
+		return nil // nothing new, skip rewriting the state file
+	}
+	if len(lines) > 0 {
+		events := make([]types.InputLogEvent, len(lines))
+		now := time.Now()
+		for i, line := range lines {
+			ts := now
+			if t.extractor != nil {
+				ts = t.extractor.Extract(line)
+			}
+			events[i] = types.InputLogEvent{Message: aws.String(line), Timestamp: aws.Int64(ts.UnixMilli())}
+		}
+		if err := putAll(ctx, s.client, s.groupName, t.stream, events); err != nil {
+			return err
+		}
+	}
+	s.state.Files[t.path] = t.state()
+	return s.state.save()
+}
+
+// poll reads and ships new lines from every tailed file, handling rotation
+// and truncation. Returns the number of lines shipped.
+func (s *fileShipper) poll(ctx context.Context, final bool) (int, error) {
+	shipped := 0
+	paths := make([]string, 0, len(s.files))
+	for path := range s.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		t := s.files[path]
+		lines, err := t.readLines(final)
+		if err != nil {
+			return shipped, err
+		}
+		replaced, truncated := t.rotation()
+		if err := s.ship(ctx, t, lines); err != nil {
+			return shipped, err
+		}
+		shipped += len(lines)
+		for replaced {
+			// drain whatever was written to the old file before it moved
+			rest, err := t.readLines(true)
+			if err != nil {
+				return shipped, err
+			}
+			if len(rest) == 0 {
+				break
+			}
+			if err := s.ship(ctx, t, rest); err != nil {
+				return shipped, err
+			}
+			shipped += len(rest)
+		}
+		switch {
+		case replaced:
+			t.f.Close()
+			delete(s.files, path)
+			delete(s.state.Files, path)
+			// picked up again by the next discover if a new file exists
+		case truncated:
+			log.Printf("%s was truncated, reading from the start", path)
+			t.offset = 0
+		}
+	}
+	return shipped, nil
+}
+
+// Run ships every matching file. Without follow, each file is read to EOF
+// once. With follow, files are tailed until ctx is cancelled, and new files
+// matching the patterns are picked up as they appear.
+func (s *fileShipper) Run(ctx context.Context, follow bool) error {
+	defer s.close()
+	if err := s.discover(); err != nil {
+		return err
+	}
+	if !follow {
+		for {
+			n, err := s.poll(ctx, true)
+			if err != nil || n == 0 {
+				return err
+			}
+		}
+	}
+	for {
+		if err := s.discover(); err != nil {
+			return err
+		}
+		n, err := s.poll(ctx, false)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			continue // keep catching up without waiting
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(tailPollInterval):
+		}
+	}
+}
+
+func (s *fileShipper) close() {
+	for _, t := range s.files {
+		t.f.Close()
+	}
+}
+
+// putAll uploads events synchronously, split into batches within the
+// PutLogEvents limits. Used where the caller must know the events were
+// accepted before moving on (e.g. before committing file offsets).
+func putAll(ctx context.Context, client interfaces.CloudWatchLogsClient, groupName, streamName string, events []types.InputLogEvent) error {
+	var batch eventBatch
+	for _, e := range events {
+		if !batch.fits(e) {
+			if err := sendBatch(ctx, client, groupName, streamName, batch.events); err != nil {
+				return err
+			}
+			batch.reset()
+		}
+		batch.add(e)
+	}
+	if len(batch.events) == 0 {
+		return nil
+	}
+	return sendBatch(ctx, client, groupName, streamName, batch.events)
+}
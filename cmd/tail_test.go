@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func shippedMessages(client *mockPutClient) []string {
+	var result []string
+	for _, batch := range client.batches {
+		for _, e := range batch {
+			result = append(result, *e.Message)
+		}
+	}
+	return result
+}
+
+func testShipper(t *testing.T, client *mockPutClient, pattern, stateFile string) *fileShipper {
+	t.Helper()
+	state, err := loadShipState(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newFileShipper(client, "group", []string{pattern}, "{hostname}/{filename}", state)
+	s.hostname = "host"
+	if err := s.discover(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// TestFileShipperAppend verifies that only newly appended complete lines are
+// shipped on each poll, and a partial trailing line waits for its newline.
+func TestFileShipperAppend(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "one\ntwo\npart")
+
+	client := &mockPutClient{}
+	s := testShipper(t, client, filepath.Join(dir, "*.log"), "")
+	defer s.close()
+
+	if _, err := s.poll(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, "ial\nthree\n")
+	if _, err := s.poll(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	got := shippedMessages(client)
+	want := []string{"one", "two", "partial", "three"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+	if client.batchDest[0] != "host/app.log" {
+		t.Fatalf("expected stream host/app.log, got %s", client.batchDest[0])
+	}
+}
+
+// TestFileShipperLongLine verifies that a line too long for one event is
+// shipped in parts that each fit PutLogEvents' limit, even before its
+// newline is written, and that nothing is lost or repeated.
+func TestFileShipperLongLine(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	long := strings.Repeat("x", 2*maxEventSize+100)
+	appendFile(t, path, long)
+
+	client := &mockPutClient{}
+	s := testShipper(t, client, filepath.Join(dir, "*.log"), "")
+	defer s.close()
+
+	if _, err := s.poll(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+	got := shippedMessages(client)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 full parts before the newline, got %d", len(got))
+	}
+	appendFile(t, path, "\nnext\n")
+	if _, err := s.poll(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	got = shippedMessages(client)
+	if got[len(got)-1] != "next" {
+		t.Fatalf("expected the next line last, got %.10q", got[len(got)-1])
+	}
+	total := 0
+	for _, m := range got[:len(got)-1] {
+		if len(m)+eventOverhead > maxEventSize {
+			t.Errorf("event of %d bytes exceeds the event limit", len(m))
+		}
+		total += len(m)
+	}
+	if total != len(long) {
+		t.Errorf("shipped %d bytes of the long line, want %d", total, len(long))
+	}
+}
+
+// TestFileShipperRename verifies that lines written to a file just before it
+// is renamed are still shipped, and the new file at the path is read from
+// the start.
+func TestFileShipperRename(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "old-1\n")
+
+	client := &mockPutClient{}
+	s := testShipper(t, client, path, "")
+	defer s.close()
+	s.poll(context.Background(), false)
+
+	appendFile(t, path, "old-2\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, "new-1\n")
+
+	s.poll(context.Background(), false)
+	s.discover()
+	s.poll(context.Background(), false)
+
+	got := shippedMessages(client)
+	want := []string{"old-1", "old-2", "new-1"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+// TestFileShipperTruncate verifies that a file truncated in place (e.g. by
+// copytruncate rotation) is read again from the beginning.
+func TestFileShipperTruncate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "first line\n")
+
+	client := &mockPutClient{}
+	s := testShipper(t, client, path, "")
+	defer s.close()
+	s.poll(context.Background(), false)
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	s.poll(context.Background(), false)
+	appendFile(t, path, "again\n")
+	s.poll(context.Background(), false)
+
+	got := shippedMessages(client)
+	if len(got) != 2 || got[1] != "again" {
+		t.Fatalf("expected [first line again], got %v", got)
+	}
+}
+
+// TestFileShipperResumesFromState verifies that a restarted shipper with the
+// same state file neither re-sends old lines nor skips new ones.
+func TestFileShipperResumesFromState(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	stateFile := filepath.Join(dir, "state", "put.json")
+	appendFile(t, path, "a\nb\n")
+
+	first := &mockPutClient{}
+	s := testShipper(t, first, path, stateFile)
+	if err := s.Run(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	appendFile(t, path, "c\n")
+	second := &mockPutClient{}
+	s = testShipper(t, second, path, stateFile)
+	if err := s.Run(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	got := shippedMessages(second)
+	if len(got) != 1 || got[0] != "c" {
+		t.Fatalf("expected only [c] after restart, got %v", got)
+	}
+}
+
+func TestStreamNameTemplate(t *testing.T) {
+	s := newFileShipper(nil, "group", nil, "{hostname}/{path}", &shipState{})
+	s.hostname = "web-1"
+	if got := s.streamName("/var/log/app.log"); got != "web-1/var/log/app.log" {
+		t.Fatalf("unexpected stream name %q", got)
+	}
+}
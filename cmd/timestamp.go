@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampExtractor pulls event timestamps out of input lines for `cwl put`,
+// either from a JSON field or from a regex match. Lines without a usable
+// timestamp inherit the previous line's timestamp, so continuation lines
+// (stack traces, wrapped output) stay next to the line that produced them.
+type timestampExtractor struct {
+	field  []string // dotted JSON path, split on "."
+	re     *regexp.Regexp
+	format string
+	last   time.Time
+	now    func() time.Time
+}
+
+// newTimestampExtractor returns nil when neither a field nor a regex is set,
+// meaning every line is stamped with the current time.
+func newTimestampExtractor(field, pattern, format string) (*timestampExtractor, error) {
+	if field == "" && pattern == "" {
+		return nil, nil
+	}
+	if field != "" && pattern != "" {
+		return nil, fmt.Errorf("--timestamp-field and --timestamp-regex are mutually exclusive")
+	}
+	if err := checkTimestampFormat(format); err != nil {
+		return nil, err
+	}
+	x := &timestampExtractor{format: format, now: time.Now}
+	if field != "" {
+		x.field = strings.Split(field, ".")
+	}
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --timestamp-regex: %w", err)
+		}
+		x.re = re
+	}
+	return x, nil
+}
+
+// Extract returns the timestamp for a line, falling back to the previous
+// line's timestamp (or the current time for the first line).
+func (x *timestampExtractor) Extract(line string) time.Time {
+	value, ok := x.find(line)
+	if ok {
+		if t, err := parseTimestamp(value, x.format); err == nil {
+			x.last = t
+			return t
+		}
+	}
+	if x.last.IsZero() {
+		x.last = x.now()
+	}
+	return x.last
+}
+
+// find locates the raw timestamp text in a line.
+func (x *timestampExtractor) find(line string) (string, bool) {
+	if x.re != nil {
+		match := x.re.FindStringSubmatch(line)
+		if match == nil {
+			return "", false
+		}
+		if i := x.re.SubexpIndex("ts"); i > 0 {
+			return match[i], true
+		}
+		if len(match) > 1 {
+			return match[1], true
+		}
+		return match[0], true
+	}
+
+	// cheap check to skip decoding plain text lines
+	if !strings.HasPrefix(strings.TrimSpace(line), "{") {
+		return "", false
+	}
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return "", false
+	}
+	var cur interface{} = obj
+	for _, key := range x.field {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if cur, ok = m[key]; !ok {
+			return "", false
+		}
+	}
+	switch v := cur.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	}
+	return "", false
+}
+
+// checkTimestampFormat rejects a --timestamp-format that parseTimestamp
+// would fail on for every line, such as a misspelled name. A Go layout has
+// to carry a date or a time of day: formatting a reference time with it and
+// parsing the result back must recover one of them.
+func checkTimestampFormat(format string) error {
+	switch format {
+	case "", "rfc3339", "unix", "unixms":
+		return nil
+	}
+	ref := time.Date(1999, 10, 28, 19, 38, 47, 0, time.UTC)
+	t, err := time.Parse(format, ref.Format(format))
+	if err == nil {
+		y, m, d := t.Date()
+		if y == 1999 && m == 10 && d == 28 || t.Hour() == 19 && t.Minute() == 38 {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --timestamp-format %q: expected rfc3339, unix, unixms, or a Go time layout such as \"2006-01-02 15:04:05\"", format)
+}
+
+// parseTimestamp parses a value according to --timestamp-format, which is
+// one of rfc3339, unix (seconds, fractional allowed), unixms, or a Go time
+// layout such as "2006-01-02 15:04:05".
+func parseTimestamp(value, format string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	switch format {
+	case "", "rfc3339":
+		return time.Parse(time.RFC3339Nano, value)
+	case "unix":
+		secs, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.UnixMilli(int64(secs * 1000)), nil
+	case "unixms":
+		ms, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.UnixMilli(ms), nil
+	default:
+		return time.Parse(format, value)
+	}
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name   string
+		value  string
+		format string
+	}{
+		{name: "rfc3339", value: "2024-01-02T15:04:05Z", format: "rfc3339"},
+		{name: "default is rfc3339", value: "2024-01-02T15:04:05Z", format: ""},
+		{name: "unix seconds", value: "1704207845", format: "unix"},
+		{name: "unix millis", value: "1704207845000", format: "unixms"},
+		{name: "go layout", value: "2024-01-02 15:04:05", format: "2006-01-02 15:04:05"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTimestamp(tt.value, tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(want) {
+				t.Fatalf("expected %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+// TestExtractJSONField verifies extraction from a nested JSON field, with
+// both string and numeric values.
+func TestExtractJSONField(t *testing.T) {
+	x, err := newTimestampExtractor("meta.ts", "", "unixms")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := x.Extract(`{"msg":"hi","meta":{"ts":1704207845000}}`)
+	if got.UnixMilli() != 1704207845000 {
+		t.Fatalf("expected 1704207845000, got %d", got.UnixMilli())
+	}
+}
+
+// TestExtractRegex verifies that the named group "ts" is preferred over the
+// first capture group.
+func TestExtractRegex(t *testing.T) {
+	x, err := newTimestampExtractor("", `^(\w+) (?P<ts>\S+)`, "rfc3339")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := x.Extract("INFO 2024-01-02T15:04:05Z started")
+	if got.Unix() != 1704207845 {
+		t.Fatalf("expected 1704207845, got %d", got.Unix())
+	}
+}
+
+// TestExtractFallsBackToPreviousLine verifies that lines without a timestamp
+// (e.g. stack trace continuations) reuse the previous line's timestamp, and
+// that the very first line falls back to the current time.
+func TestExtractFallsBackToPreviousLine(t *testing.T) {
+	x, err := newTimestampExtractor("", `^(\S+)`, "rfc3339")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	x.now = func() time.Time { return now }
+
+	if got := x.Extract("no timestamp here"); !got.Equal(now) {
+		t.Fatalf("expected first line to use now, got %v", got)
+	}
+	first := x.Extract("2024-01-02T15:04:05Z boom")
+	if got := x.Extract("    at com.example.Foo"); !got.Equal(first) {
+		t.Fatalf("expected continuation to reuse %v, got %v", first, got)
+	}
+}
+
+func TestNewTimestampExtractorValidation(t *testing.T) {
+	if x, err := newTimestampExtractor("", "", "rfc3339"); x != nil || err != nil {
+		t.Fatalf("expected nil extractor and no error, got %v, %v", x, err)
+	}
+	if _, err := newTimestampExtractor("ts", "^(.*)$", "rfc3339"); err == nil {
+		t.Fatal("expected error when both field and regex are set")
+	}
+	if _, err := newTimestampExtractor("", "(", "rfc3339"); err == nil {
+		t.Fatal("expected error for invalid regex")
+	}
+}
+
+// TestNewTimestampExtractorRejectsUnknownFormat verifies that a format that
+// is neither a known name nor a Go layout is rejected up front, naming the
+// accepted formats, while real layouts are accepted.
+func TestNewTimestampExtractorRejectsUnknownFormat(t *testing.T) {
+	for _, format := range []string{"epoch", "unix_ms", "iso8601", "RFC3339"} {
+		_, err := newTimestampExtractor("ts", "", format)
+		if err == nil || !strings.Contains(err.Error(), "rfc3339, unix, unixms") {
+			t.Errorf("format %q: expected error naming the accepted formats, got %v", format, err)
+		}
+	}
+	for _, format := range []string{"", "unix", "2006-01-02 15:04:05", "15:04:05", "Jan _2 15:04:05", time.RFC1123Z} {
+		if _, err := newTimestampExtractor("ts", "", format); err != nil {
+			t.Errorf("format %q: unexpected error: %v", format, err)
+		}
+	}
+}
@@ -2,7 +2,7 @@ package fetch
 
 import (
 	"context"
-	"fmt"
+	"iter"
 	"net"
 	"net/http"
 	"time"
@@ -20,6 +20,14 @@ import (
 // hanging for minutes on retries. The SDK's default retry behavior for
 // throttling is preserved since we don't override MaxAttempts.
 func CreateClient(profileName string) (interfaces.CloudWatchLogsClient, error) {
+	client, _, err := CreateRegionalClient(profileName, "")
+	return client, err
+}
+
+// CreateRegionalClient is CreateClient for a specific region. An empty
+// region uses the profile's configured region. The resolved region is
+// returned so callers can report where results came from.
+func CreateRegionalClient(profileName, region string) (interfaces.CloudWatchLogsClient, string, error) {
 	httpClient := &http.Client{
 		Timeout: 5 * time.Second,
 		Transport: &http.Transport{
@@ -29,101 +37,54 @@ func CreateClient(profileName string) (interfaces.CloudWatchLogsClient, error) {
 			TLSHandshakeTimeout: 2 * time.Second,
 		},
 	}
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
+	opts := []func(*config.LoadOptions) error{
 		config.WithSharedConfigProfile(profileName),
 		config.WithHTTPClient(httpClient),
-	)
+	}
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	cfg, err := config.LoadDefaultConfig(context.TODO(), opts...)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	return cloudwatchlogs.NewFromConfig(cfg), nil
+	return cloudwatchlogs.NewFromConfig(cfg), cfg.Region, nil
 }
 
 // FetchLogGroups retrieves log groups, optionally filtered server-side by pattern.
 // The pattern parameter maps to DescribeLogGroups' LogGroupNamePattern field.
-func FetchLogGroups(client interfaces.CloudWatchLogsClient, pattern string) ([]types.LogGroup, error) {
-	groups := make([]types.LogGroup, 0)
-	var nextToken *string
-
-	for {
-		input := &cloudwatchlogs.DescribeLogGroupsInput{NextToken: nextToken}
-		if pattern != "" {
-			input.LogGroupNamePattern = &pattern
-		}
-		output, err := client.DescribeLogGroups(context.TODO(), input)
-		if err != nil {
-			return nil, err
-		}
-		groups = append(groups, output.LogGroups...)
-		if output.NextToken != nil {
-			nextToken = output.NextToken
-		} else {
-			break
-		}
+func FetchLogGroups(ctx context.Context, client interfaces.CloudWatchLogsClient, pattern string) ([]types.LogGroup, error) {
+	input := &cloudwatchlogs.DescribeLogGroupsInput{}
+	if pattern != "" {
+		input.LogGroupNamePattern = &pattern
 	}
-	return groups, nil
+	return collect(LogGroups(ctx, client, input, PageOptions{}))
 }
 
-func FetchLogStreams(client interfaces.CloudWatchLogsClient, logGroupName string, maxResults int) ([]types.LogStream, error) {
-	streams := make([]types.LogStream, 0)
-	var nextToken *string
-
-	for {
-		output, err := client.DescribeLogStreams(context.TODO(), &cloudwatchlogs.DescribeLogStreamsInput{
-			LogGroupName: &logGroupName,
-			Limit:        aws.Int32(50),
-			OrderBy:      types.OrderByLastEventTime,
-			Descending:   aws.Bool(true),
-			NextToken:    nextToken,
-		})
-		if err != nil {
-			return nil, err
-		}
-		streams = append(streams, output.LogStreams...)
-		if len(streams) >= maxResults {
-			break
-		}
-		if output.NextToken != nil {
-			nextToken = output.NextToken
-		} else {
-			break
-		}
+// streamsInput lists a group's streams, most recently written first.
+func streamsInput(logGroupName string) *cloudwatchlogs.DescribeLogStreamsInput {
+	return &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName: &logGroupName,
+		Limit:        aws.Int32(50),
+		OrderBy:      types.OrderByLastEventTime,
+		Descending:   aws.Bool(true),
 	}
-	return streams, nil
 }
 
-var ErrMaxStreamsReached = fmt.Errorf("max streams reached")
-
-func FetchLogStreamsStreaming(client interfaces.CloudWatchLogsClient, logGroupName string, callback func([]types.LogStream) error) error {
-	var nextToken *string
-
-	for {
-		output, err := client.DescribeLogStreams(context.TODO(), &cloudwatchlogs.DescribeLogStreamsInput{
-			LogGroupName: &logGroupName,
-			Limit:        aws.Int32(50),
-			OrderBy:      types.OrderByLastEventTime,
-			Descending:   aws.Bool(true),
-			NextToken:    nextToken,
-		})
+func FetchLogStreamsStreaming(ctx context.Context, client interfaces.CloudWatchLogsClient, logGroupName string, callback func([]types.LogStream) error) error {
+	for streams, err := range LogStreamPages(ctx, client, streamsInput(logGroupName), PageOptions{}) {
 		if err != nil {
 			return err
 		}
-		if len(output.LogStreams) > 0 {
-			if err := callback(output.LogStreams); err != nil {
-				return err
-			}
-		}
-		if output.NextToken != nil {
-			nextToken = output.NextToken
-		} else {
-			break
+		if err := callback(streams); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-func FetchLastLogEvents(client interfaces.CloudWatchLogsClient, logGroupName, logStreamName string, limit int32) ([]types.OutputLogEvent, error) {
-	output, err := client.GetLogEvents(context.TODO(), &cloudwatchlogs.GetLogEventsInput{
+func FetchLastLogEvents(ctx context.Context, client interfaces.CloudWatchLogsClient, logGroupName, logStreamName string, limit int32) ([]types.OutputLogEvent, error) {
+	output, err := client.GetLogEvents(ctx, &cloudwatchlogs.GetLogEventsInput{
 		LogGroupName:  &logGroupName,
 		LogStreamName: &logStreamName,
 		StartFromHead: aws.Bool(false),
@@ -135,65 +96,33 @@ func FetchLastLogEvents(client interfaces.CloudWatchLogsClient, logGroupName, lo
 	return output.Events, nil
 }
 
-// FetchLogEvents retrieves all events for a stream. Terminates when the
-// pagination token stops changing (same token = end of stream).
-func FetchLogEvents(client interfaces.CloudWatchLogsClient, logGroupName, logStreamName string) ([]types.OutputLogEvent, error) {
-	events := make([]types.OutputLogEvent, 0)
-	var nextToken *string
-
-	for {
-		output, err := client.GetLogEvents(context.TODO(), &cloudwatchlogs.GetLogEventsInput{
-			LogGroupName:  &logGroupName,
-			LogStreamName: &logStreamName,
-			StartFromHead: aws.Bool(true),
-			NextToken:     nextToken,
-		})
-		if err != nil {
-			return nil, err
-		}
-		events = append(events, output.Events...)
-		if nextToken != nil && output.NextForwardToken != nil && *nextToken == *output.NextForwardToken {
-			break
-		}
-		nextToken = output.NextForwardToken
-	}
-	return events, nil
-}
-
 // FetchLogEventsStreaming retrieves all events for a stream, delivering batches
-// via callback. Terminates when the pagination token stops changing.
-func FetchLogEventsStreaming(client interfaces.CloudWatchLogsClient, logGroupName, logStreamName string, callback func([]types.OutputLogEvent) error) error {
-	var nextToken *string
-
-	for {
-		output, err := client.GetLogEvents(context.TODO(), &cloudwatchlogs.GetLogEventsInput{
-			LogGroupName:  &logGroupName,
-			LogStreamName: &logStreamName,
-			StartFromHead: aws.Bool(true),
-			Limit:         aws.Int32(10000),
-			NextToken:     nextToken,
-		})
+// via callback.
+func FetchLogEventsStreaming(ctx context.Context, client interfaces.CloudWatchLogsClient, logGroupName, logStreamName string, callback func([]types.OutputLogEvent) error) error {
+	input := &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  &logGroupName,
+		LogStreamName: &logStreamName,
+		StartFromHead: aws.Bool(true),
+		Limit:         aws.Int32(10000),
+	}
+	for events, err := range LogEventPages(ctx, client, input, EventOptions{}) {
 		if err != nil {
 			return err
 		}
-		if len(output.Events) > 0 {
-			if err := callback(output.Events); err != nil {
+		if len(events) > 0 {
+			if err := callback(events); err != nil {
 				return err
 			}
 		}
-		if nextToken != nil && output.NextForwardToken != nil && *nextToken == *output.NextForwardToken {
-			break
-		}
-		nextToken = output.NextForwardToken
 	}
 	return nil
 }
 
-func FetchNewLogEvents(client interfaces.CloudWatchLogsClient, logGroupName, logStreamName string, startTime *int64) ([]types.OutputLogEvent, error) {
+func FetchNewLogEvents(ctx context.Context, client interfaces.CloudWatchLogsClient, logGroupName, logStreamName string, startTime *int64) ([]types.OutputLogEvent, error) {
 	if startTime == nil {
 		return nil, nil
 	}
-	output, err := client.GetLogEvents(context.TODO(), &cloudwatchlogs.GetLogEventsInput{
+	output, err := client.GetLogEvents(ctx, &cloudwatchlogs.GetLogEventsInput{
 		LogGroupName:  &logGroupName,
 		LogStreamName: &logStreamName,
 		StartTime:     aws.Int64(*startTime + 1),
@@ -204,3 +133,15 @@ func FetchNewLogEvents(client interfaces.CloudWatchLogsClient, logGroupName, log
 	}
 	return output.Events, nil
 }
+
+// collect drains an iterator into a slice.
+func collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	result := make([]T, 0)
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
@@ -48,6 +48,34 @@ func (m *MockCloudWatchLogsClient) GetQueryResults(ctx context.Context, params *
 	return nil, nil
 }
 
+func (m *MockCloudWatchLogsClient) CreateLogGroup(ctx context.Context, params *cloudwatchlogs.CreateLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	return nil, nil
+}
+
+func (m *MockCloudWatchLogsClient) DeleteLogGroup(ctx context.Context, params *cloudwatchlogs.DeleteLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteLogGroupOutput, error) {
+	return nil, nil
+}
+
+func (m *MockCloudWatchLogsClient) PutRetentionPolicy(ctx context.Context, params *cloudwatchlogs.PutRetentionPolicyInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+	return nil, nil
+}
+
+func (m *MockCloudWatchLogsClient) DeleteRetentionPolicy(ctx context.Context, params *cloudwatchlogs.DeleteRetentionPolicyInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DeleteRetentionPolicyOutput, error) {
+	return nil, nil
+}
+
+func (m *MockCloudWatchLogsClient) ListTagsForResource(ctx context.Context, params *cloudwatchlogs.ListTagsForResourceInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.ListTagsForResourceOutput, error) {
+	return nil, nil
+}
+
+func (m *MockCloudWatchLogsClient) TagResource(ctx context.Context, params *cloudwatchlogs.TagResourceInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.TagResourceOutput, error) {
+	return nil, nil
+}
+
+func (m *MockCloudWatchLogsClient) UntagResource(ctx context.Context, params *cloudwatchlogs.UntagResourceInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.UntagResourceOutput, error) {
+	return nil, nil
+}
+
 // Ensure MockCloudWatchLogsClient implements the interface
 var _ interfaces.CloudWatchLogsClient = (*MockCloudWatchLogsClient)(nil)
 
@@ -60,7 +88,7 @@ func TestFetchLogGroups(t *testing.T) {
 		},
 	}
 
-	groups, err := FetchLogGroups(mockClient, "")
+	groups, err := FetchLogGroups(context.Background(), mockClient, "")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -0,0 +1,309 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/derricw/cwl/interfaces"
+)
+
+// The iterators below own CloudWatch's token loops, so callers range over
+// groups, streams, events or query rows and stop whenever they like:
+//
+//	for group, err := range fetch.LogGroups(ctx, client, input, fetch.PageOptions{}) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// An error is yielded once, as the last value, and a cancelled ctx ends
+// iteration with ctx's error. Inputs are copied, never modified.
+
+// PageOptions bound a paginated listing. Zero values mean no bound.
+type PageOptions struct {
+	// Limit stops iteration after this many items.
+	Limit int
+	// PageTimeout bounds each API call, on top of the iterator's ctx.
+	PageTimeout time.Duration
+}
+
+// pageContext returns the context for one API call.
+func (o PageOptions) pageContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.PageTimeout > 0 {
+		return context.WithTimeout(ctx, o.PageTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// paginate yields the pages returned by next, passing each call the token
+// the previous one returned, until a call returns no token.
+func paginate[T any](ctx context.Context, opts PageOptions, next func(ctx context.Context, token *string) ([]T, *string, error)) iter.Seq2[[]T, error] {
+	return func(yield func([]T, error) bool) {
+		var token *string
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			pageCtx, cancel := opts.pageContext(ctx)
+			page, nextToken, err := next(pageCtx, token)
+			cancel()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if len(page) > 0 && !yield(page, nil) {
+				return
+			}
+			if nextToken == nil || *nextToken == "" {
+				return
+			}
+			token = nextToken
+		}
+	}
+}
+
+// items flattens pages, stopping after limit items when limit > 0.
+func items[T any](pages iter.Seq2[[]T, error], limit int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		n := 0
+		for page, err := range pages {
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page {
+				if !yield(item, nil) {
+					return
+				}
+				n++
+				if limit > 0 && n >= limit {
+					return
+				}
+			}
+		}
+	}
+}
+
+// LogGroupPages yields DescribeLogGroups pages for input. Limit counts
+// pages here; use LogGroups to limit groups.
+func LogGroupPages(ctx context.Context, client interfaces.CloudWatchLogsClient, input *cloudwatchlogs.DescribeLogGroupsInput, opts PageOptions) iter.Seq2[[]types.LogGroup, error] {
+	in := *input
+	pages := paginate(ctx, opts, func(ctx context.Context, token *string) ([]types.LogGroup, *string, error) {
+		in.NextToken = token
+		output, err := client.DescribeLogGroups(ctx, &in)
+		if err != nil {
+			return nil, nil, err
+		}
+		return output.LogGroups, output.NextToken, nil
+	})
+	return limitPages(pages, opts.Limit)
+}
+
+// LogGroups yields the groups matching input.
+func LogGroups(ctx context.Context, client interfaces.CloudWatchLogsClient, input *cloudwatchlogs.DescribeLogGroupsInput, opts PageOptions) iter.Seq2[types.LogGroup, error] {
+	return items(LogGroupPages(ctx, client, input, PageOptions{PageTimeout: opts.PageTimeout}), opts.Limit)
+}
+
+// LogStreamPages yields DescribeLogStreams pages for input. Limit counts
+// pages here; use LogStreams to limit streams.
+func LogStreamPages(ctx context.Context, client interfaces.CloudWatchLogsClient, input *cloudwatchlogs.DescribeLogStreamsInput, opts PageOptions) iter.Seq2[[]types.LogStream, error] {
+	in := *input
+	pages := paginate(ctx, opts, func(ctx context.Context, token *string) ([]types.LogStream, *string, error) {
+		in.NextToken = token
+		output, err := client.DescribeLogStreams(ctx, &in)
+		if err != nil {
+			return nil, nil, err
+		}
+		return output.LogStreams, output.NextToken, nil
+	})
+	return limitPages(pages, opts.Limit)
+}
+
+// LogStreams yields the streams matching input, in the order it asks for.
+func LogStreams(ctx context.Context, client interfaces.CloudWatchLogsClient, input *cloudwatchlogs.DescribeLogStreamsInput, opts PageOptions) iter.Seq2[types.LogStream, error] {
+	return items(LogStreamPages(ctx, client, input, PageOptions{PageTimeout: opts.PageTimeout}), opts.Limit)
+}
+
+// limitPages stops after limit pages when limit > 0.
+func limitPages[T any](pages iter.Seq2[[]T, error], limit int) iter.Seq2[[]T, error] {
+	if limit <= 0 {
+		return pages
+	}
+	return func(yield func([]T, error) bool) {
+		n := 0
+		for page, err := range pages {
+			if !yield(page, err) || err != nil {
+				return
+			}
+			if n++; n >= limit {
+				return
+			}
+		}
+	}
+}
+
+// EventOptions configure reading a stream with LogEvents.
+type EventOptions struct {
+	PageOptions
+	// Follow keeps polling once the end of the stream is reached. Polls
+	// wait MinInterval after new events, doubling up to MaxInterval while
+	// none arrive. Unset intervals default to DefaultMinInterval and
+	// DefaultMaxInterval.
+	Follow      bool
+	MinInterval time.Duration
+	MaxInterval time.Duration
+	// MaxEmptyPages ends a read that isn't following after this many
+	// consecutive empty pages. GetLogEvents can hand out fresh tokens for
+	// empty pages of a sparse stream indefinitely.
+	MaxEmptyPages int
+}
+
+// Default polling intervals for following a stream.
+const (
+	DefaultMinInterval = time.Second
+	DefaultMaxInterval = 16 * time.Second
+)
+
+// intervals returns the polling bounds, never zero, so that following an
+// idle stream can't spin on GetLogEvents.
+func (o EventOptions) intervals() (minInterval, maxInterval time.Duration) {
+	minInterval, maxInterval = o.MinInterval, o.MaxInterval
+	if minInterval <= 0 {
+		minInterval = DefaultMinInterval
+	}
+	if maxInterval <= 0 {
+		maxInterval = DefaultMaxInterval
+	}
+	return minInterval, max(minInterval, maxInterval)
+}
+
+// LogEventPages yields GetLogEvents pages for input, empty ones included,
+// since callers following a stream poll through them. GetLogEvents signals
+// the end of a stream by returning the token it was given, or no token.
+func LogEventPages(ctx context.Context, client interfaces.CloudWatchLogsClient, input *cloudwatchlogs.GetLogEventsInput, opts EventOptions) iter.Seq2[[]types.OutputLogEvent, error] {
+	in := *input
+	return func(yield func([]types.OutputLogEvent, error) bool) {
+		minInterval, maxInterval := opts.intervals()
+		interval := minInterval
+		emptyPages := 0
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			pageCtx, cancel := opts.pageContext(ctx)
+			output, err := client.GetLogEvents(pageCtx, &in)
+			cancel()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(output.Events, nil) {
+				return
+			}
+
+			if len(output.Events) > 0 {
+				interval = minInterval
+				emptyPages = 0
+			} else {
+				interval = min(maxInterval, interval*2)
+				emptyPages++
+			}
+
+			next := output.NextForwardToken
+			atEnd := next == nil || (in.NextToken != nil && *in.NextToken == *next)
+			switch {
+			case atEnd && !opts.Follow:
+				return
+			case atEnd:
+				if !sleep(ctx, interval) {
+					yield(nil, ctx.Err())
+					return
+				}
+			case len(output.Events) == 0 && !opts.Follow && opts.MaxEmptyPages > 0 && emptyPages >= opts.MaxEmptyPages:
+				return
+			}
+			if next != nil {
+				in.NextToken = next
+			}
+		}
+	}
+}
+
+// LogEvents yields the events of the stream input names.
+func LogEvents(ctx context.Context, client interfaces.CloudWatchLogsClient, input *cloudwatchlogs.GetLogEventsInput, opts EventOptions) iter.Seq2[types.OutputLogEvent, error] {
+	limit := opts.Limit
+	opts.Limit = 0
+	return items(LogEventPages(ctx, client, input, opts), limit)
+}
+
+// QueryOptions configure waiting for a Logs Insights query with
+// QueryResults.
+type QueryOptions struct {
+	PageOptions
+	// Interval is the wait between polls while the query runs.
+	Interval time.Duration
+	// OnStatus, if set, is called with the status of each poll that finds
+	// the query still running.
+	OnStatus func(types.QueryStatus)
+}
+
+// QueryResults waits for a started query to complete, then yields its
+// rows. A query that fails, is cancelled or times out yields an error.
+func QueryResults(ctx context.Context, client interfaces.CloudWatchLogsClient, queryID string, opts QueryOptions) iter.Seq2[[]types.ResultField, error] {
+	return func(yield func([]types.ResultField, error) bool) {
+		for {
+			if !sleep(ctx, opts.Interval) {
+				yield(nil, ctx.Err())
+				return
+			}
+			pageCtx, cancel := opts.pageContext(ctx)
+			output, err := client.GetQueryResults(pageCtx, &cloudwatchlogs.GetQueryResultsInput{QueryId: &queryID})
+			cancel()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			switch output.Status {
+			case types.QueryStatusComplete:
+				for i, row := range output.Results {
+					if opts.Limit > 0 && i >= opts.Limit {
+						return
+					}
+					if !yield(row, nil) {
+						return
+					}
+				}
+				return
+			case types.QueryStatusFailed, types.QueryStatusCancelled, types.QueryStatusTimeout:
+				yield(nil, fmt.Errorf("query %s: %s", queryID, output.Status))
+				return
+			}
+			if opts.OnStatus != nil {
+				opts.OnStatus(output.Status)
+			}
+		}
+	}
+}
+
+// sleep waits for d, returning false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
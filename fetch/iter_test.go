@@ -0,0 +1,170 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// pagedClient serves streams and events in pages of two, keyed by token.
+type pagedClient struct {
+	MockCloudWatchLogsClient
+	streams []string
+	events  [][]string // pages of messages; the last page's token repeats
+	nilEnd  bool       // end the events with no token instead of a repeat
+	queries []types.QueryStatus
+	calls   int
+}
+
+func (c *pagedClient) DescribeLogStreams(ctx context.Context, params *cloudwatchlogs.DescribeLogStreamsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+	c.calls++
+	start := 0
+	if params.NextToken != nil {
+		fmt.Sscan(*params.NextToken, &start)
+	}
+	end := min(start+2, len(c.streams))
+	output := &cloudwatchlogs.DescribeLogStreamsOutput{}
+	for _, name := range c.streams[start:end] {
+		output.LogStreams = append(output.LogStreams, types.LogStream{LogStreamName: aws.String(name)})
+	}
+	if end < len(c.streams) {
+		output.NextToken = aws.String(fmt.Sprint(end))
+	}
+	return output, nil
+}
+
+func (c *pagedClient) GetLogEvents(ctx context.Context, params *cloudwatchlogs.GetLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetLogEventsOutput, error) {
+	c.calls++
+	page := 0
+	if params.NextToken != nil {
+		fmt.Sscan(*params.NextToken, &page)
+	}
+	output := &cloudwatchlogs.GetLogEventsOutput{}
+	if page < len(c.events) {
+		for _, m := range c.events[page] {
+			output.Events = append(output.Events, types.OutputLogEvent{Message: aws.String(m)})
+		}
+	}
+	next := min(page+1, len(c.events))
+	if !(c.nilEnd && next == len(c.events)) {
+		output.NextForwardToken = aws.String(fmt.Sprint(next))
+	}
+	return output, nil
+}
+
+func (c *pagedClient) GetQueryResults(ctx context.Context, params *cloudwatchlogs.GetQueryResultsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.GetQueryResultsOutput, error) {
+	status := c.queries[min(c.calls, len(c.queries)-1)]
+	c.calls++
+	output := &cloudwatchlogs.GetQueryResultsOutput{Status: status}
+	if status == types.QueryStatusComplete {
+		output.Results = [][]types.ResultField{{{Field: aws.String("@message"), Value: aws.String("a")}}, {{Field: aws.String("@message"), Value: aws.String("b")}}}
+	}
+	return output, nil
+}
+
+// TestLogStreamsPagesAndStopsEarly verifies that LogStreams follows tokens
+// across pages, honours Limit, and stops paging when the loop breaks.
+func TestLogStreamsPagesAndStopsEarly(t *testing.T) {
+	client := &pagedClient{streams: []string{"a", "b", "c", "d", "e"}}
+	input := &cloudwatchlogs.DescribeLogStreamsInput{LogGroupName: aws.String("g")}
+
+	streams, err := collect(LogStreams(context.Background(), client, input, PageOptions{}))
+	if err != nil || len(streams) != 5 {
+		t.Fatalf("got %d streams, err %v; want 5", len(streams), err)
+	}
+	if input.NextToken != nil {
+		t.Error("input was modified")
+	}
+
+	client.calls = 0
+	streams, _ = collect(LogStreams(context.Background(), client, input, PageOptions{Limit: 3}))
+	if len(streams) != 3 || client.calls != 2 {
+		t.Errorf("Limit 3: got %d streams in %d calls, want 3 in 2", len(streams), client.calls)
+	}
+
+	client.calls = 0
+	for range LogStreams(context.Background(), client, input, PageOptions{}) {
+		break
+	}
+	if client.calls != 1 {
+		t.Errorf("breaking after the first stream made %d calls, want 1", client.calls)
+	}
+}
+
+// TestLogEventsEndsAtStream verifies that LogEvents stops when the forward
+// token repeats, and also when GetLogEvents returns no token at all.
+func TestLogEventsEndsAtStream(t *testing.T) {
+	for _, nilEnd := range []bool{false, true} {
+		client := &pagedClient{events: [][]string{{"1", "2"}, {"3"}}, nilEnd: nilEnd}
+		events, err := collect(LogEvents(context.Background(), client, &cloudwatchlogs.GetLogEventsInput{}, EventOptions{}))
+		if err != nil || len(events) != 3 {
+			t.Errorf("nilEnd=%v: got %d events, err %v; want 3", nilEnd, len(events), err)
+		}
+	}
+}
+
+// TestLogEventsFollowHonorsContext verifies that following a stream polls
+// until its context is cancelled, then yields the context's error.
+func TestLogEventsFollowHonorsContext(t *testing.T) {
+	client := &pagedClient{events: [][]string{{"1"}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var got []string
+	var lastErr error
+	for event, err := range LogEvents(ctx, client, &cloudwatchlogs.GetLogEventsInput{}, EventOptions{Follow: true}) {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		got = append(got, *event.Message)
+		cancel()
+	}
+	if len(got) != 1 || !errors.Is(lastErr, context.Canceled) {
+		t.Errorf("got %v, err %v; want [1] and context.Canceled", got, lastErr)
+	}
+}
+
+// TestLogEventsFollowDefaultsIntervals verifies that following with no
+// intervals set waits between polls instead of spinning.
+func TestLogEventsFollowDefaultsIntervals(t *testing.T) {
+	client := &pagedClient{events: [][]string{{"1"}}}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	for _, err := range LogEventPages(ctx, client, &cloudwatchlogs.GetLogEventsInput{}, EventOptions{Follow: true}) {
+		if err != nil {
+			break
+		}
+	}
+	if client.calls > 2 {
+		t.Errorf("made %d calls in 100ms, want at most 2", client.calls)
+	}
+
+	minInterval, maxInterval := EventOptions{MinInterval: time.Minute}.intervals()
+	if minInterval != time.Minute || maxInterval != time.Minute {
+		t.Errorf("intervals = %v, %v; want MaxInterval raised to MinInterval", minInterval, maxInterval)
+	}
+}
+
+// TestQueryResults verifies that rows are yielded once the query completes,
+// and that a failed query is an error rather than an endless wait.
+func TestQueryResults(t *testing.T) {
+	client := &pagedClient{queries: []types.QueryStatus{types.QueryStatusRunning, types.QueryStatusComplete}}
+	var statuses []types.QueryStatus
+	rows, err := collect(QueryResults(context.Background(), client, "q", QueryOptions{
+		OnStatus: func(s types.QueryStatus) { statuses = append(statuses, s) },
+	}))
+	if err != nil || len(rows) != 2 || len(statuses) != 1 {
+		t.Errorf("got %d rows, %v statuses, err %v; want 2 rows after 1 running status", len(rows), statuses, err)
+	}
+
+	client = &pagedClient{queries: []types.QueryStatus{types.QueryStatusFailed}}
+	if _, err := collect(QueryResults(context.Background(), client, "q", QueryOptions{})); err == nil {
+		t.Error("expected an error for a failed query")
+	}
+}
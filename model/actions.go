@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -8,24 +9,38 @@ import (
 	"github.com/derricw/cwl/provider"
 )
 
+// Actions run against the context they were created with, which the model
+// cancels when the state showing their results is left. Backend errors
+// caused by that cancellation are dropped rather than shown.
+
 // Action interface for command pattern
 type Action interface {
 	Execute() tea.Cmd
 }
 
-// LoadGroupsAction loads log groups
+// LoadGroupsAction loads log groups, all of them or those matching pattern
 type LoadGroupsAction struct {
-	deps *Dependencies
+	ctx     context.Context
+	deps    *Dependencies
+	pattern string
 }
 
-func NewLoadGroupsAction(deps *Dependencies) *LoadGroupsAction {
-	return &LoadGroupsAction{deps: deps}
+func NewLoadGroupsAction(ctx context.Context, deps *Dependencies, pattern string) *LoadGroupsAction {
+	return &LoadGroupsAction{ctx: ctx, deps: deps, pattern: pattern}
 }
 
 func (a *LoadGroupsAction) Execute() tea.Cmd {
 	return func() tea.Msg {
-		logGroups, err := a.deps.Backend.FetchGroups("")
+		logGroups, err := a.deps.Backend.FetchGroups(a.ctx, a.pattern)
 		if err != nil {
+			if len(logGroups) > 0 {
+				// some sources of a composite backend failed: show the
+				// groups that loaded, then why the rest didn't
+				return tea.Sequence(
+					func() tea.Msg { return logGroupMsg(logGroups) },
+					func() tea.Msg { return errMsg{err} },
+				)()
+			}
 			return errMsg{err}
 		}
 		return logGroupMsg(logGroups)
@@ -34,13 +49,15 @@ func (a *LoadGroupsAction) Execute() tea.Cmd {
 
 // LoadStreamsAction loads log streams for a group
 type LoadStreamsAction struct {
+	ctx       context.Context
 	deps      *Dependencies
 	groupName string
 	fetchID   int
 }
 
-func NewLoadStreamsAction(deps *Dependencies, groupName string, fetchID int) *LoadStreamsAction {
+func NewLoadStreamsAction(ctx context.Context, deps *Dependencies, groupName string, fetchID int) *LoadStreamsAction {
 	return &LoadStreamsAction{
+		ctx:       ctx,
 		deps:      deps,
 		groupName: groupName,
 		fetchID:   fetchID,
@@ -54,21 +71,34 @@ func (a *LoadStreamsAction) Execute() tea.Cmd {
 	go func() {
 		defer close(ch)
 		count := 0
-		err := a.deps.Backend.FetchStreamsStreaming(a.groupName, func(streams []provider.LogStream) error {
+		err := a.deps.Backend.FetchStreamsStreaming(a.ctx, a.groupName, func(streams []provider.LogStream) error {
 			count += len(streams)
-			ch <- logStreamPartialMsg{groupName: a.groupName, streams: streams, fetchID: a.fetchID}
+			if err := send(a.ctx, ch, logStreamPartialMsg{groupName: a.groupName, streams: streams, fetchID: a.fetchID}); err != nil {
+				return err
+			}
 			if count >= 20000 {
 				return errMaxStreamsReached
 			}
 			return nil
 		})
-		if err != nil && err != errMaxStreamsReached {
+		if err != nil && err != errMaxStreamsReached && a.ctx.Err() == nil {
 			ch <- errMsg{err}
 		}
 	}()
 	return waitForStreamBatch(ch)
 }
 
+// send delivers msg to a batch channel, giving up once ctx is cancelled so
+// an abandoned fetch never blocks on a reader that has gone away.
+func send(ctx context.Context, ch chan<- tea.Msg, msg tea.Msg) error {
+	select {
+	case ch <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func waitForStreamBatch(ch <-chan tea.Msg) tea.Cmd {
 	return func() tea.Msg {
 		select {
@@ -113,13 +143,15 @@ func (a *LoadEventsAction) Execute() tea.Cmd {
 }
 
 type LoadEventsStreamingAction struct {
+	ctx        context.Context
 	deps       *Dependencies
 	groupName  string
 	streamName string
 }
 
-func NewLoadEventsStreamingAction(deps *Dependencies, groupName, streamName string) *LoadEventsStreamingAction {
+func NewLoadEventsStreamingAction(ctx context.Context, deps *Dependencies, groupName, streamName string) *LoadEventsStreamingAction {
 	return &LoadEventsStreamingAction{
+		ctx:        ctx,
 		deps:       deps,
 		groupName:  groupName,
 		streamName: streamName,
@@ -130,11 +162,10 @@ func (a *LoadEventsStreamingAction) Execute() tea.Cmd {
 	ch := make(chan tea.Msg, 100)
 	go func() {
 		defer close(ch)
-		err := a.deps.Backend.FetchEventsStreaming(a.groupName, a.streamName, func(events []provider.LogEvent) error {
-			ch <- logEventPartialMsg{events: events}
-			return nil
+		err := a.deps.Backend.FetchEventsStreaming(a.ctx, a.groupName, a.streamName, func(events []provider.LogEvent) error {
+			return send(a.ctx, ch, logEventPartialMsg{events: events})
 		})
-		if err != nil {
+		if err != nil && a.ctx.Err() == nil {
 			ch <- errMsg{err}
 		}
 	}()
@@ -161,19 +192,20 @@ func waitForBatch(ch <-chan tea.Msg) tea.Cmd {
 
 // LoadPreviewEventsAction fetches the last N events for the preview pane
 type LoadPreviewEventsAction struct {
+	ctx        context.Context
 	deps       *Dependencies
 	groupName  string
 	streamName string
 	fetchID    int
 }
 
-func NewLoadPreviewEventsAction(deps *Dependencies, groupName, streamName string, fetchID int) *LoadPreviewEventsAction {
-	return &LoadPreviewEventsAction{deps: deps, groupName: groupName, streamName: streamName, fetchID: fetchID}
+func NewLoadPreviewEventsAction(ctx context.Context, deps *Dependencies, groupName, streamName string, fetchID int) *LoadPreviewEventsAction {
+	return &LoadPreviewEventsAction{ctx: ctx, deps: deps, groupName: groupName, streamName: streamName, fetchID: fetchID}
 }
 
 func (a *LoadPreviewEventsAction) Execute() tea.Cmd {
 	return func() tea.Msg {
-		events, err := a.deps.Backend.FetchLastEvents(a.groupName, a.streamName, 20)
+		events, err := a.deps.Backend.FetchLastEvents(a.ctx, a.groupName, a.streamName, 20)
 		if err != nil {
 			return nil
 		}
@@ -182,14 +214,16 @@ func (a *LoadPreviewEventsAction) Execute() tea.Cmd {
 }
 
 type PollNewEventsAction struct {
+	ctx        context.Context
 	deps       *Dependencies
 	groupName  string
 	streamName string
 	startTime  *time.Time
 }
 
-func NewPollNewEventsAction(deps *Dependencies, groupName, streamName string, startTime *time.Time) *PollNewEventsAction {
+func NewPollNewEventsAction(ctx context.Context, deps *Dependencies, groupName, streamName string, startTime *time.Time) *PollNewEventsAction {
 	return &PollNewEventsAction{
+		ctx:        ctx,
 		deps:       deps,
 		groupName:  groupName,
 		streamName: streamName,
@@ -199,8 +233,11 @@ func NewPollNewEventsAction(deps *Dependencies, groupName, streamName string, st
 
 func (a *PollNewEventsAction) Execute() tea.Cmd {
 	return func() tea.Msg {
-		events, err := a.deps.Backend.FetchNewEvents(a.groupName, a.streamName, a.startTime)
+		events, err := a.deps.Backend.FetchNewEvents(a.ctx, a.groupName, a.streamName, a.startTime)
 		if err != nil {
+			if a.ctx.Err() == context.Canceled {
+				return nil
+			}
 			return errMsg{err}
 		}
 		return newEventsMsg(events)
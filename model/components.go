@@ -38,6 +38,20 @@ func NewGroupsList() *GroupsList {
 	return &GroupsList{Model: l}
 }
 
+// SetServerSearch advertises that enter in the filter prompt asks the
+// backend for matching groups, for backends that filter on the server.
+func (g *GroupsList) SetServerSearch(enabled bool) {
+	if !enabled {
+		g.AdditionalShortHelpKeys = nil
+		return
+	}
+	g.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "search server")),
+		}
+	}
+}
+
 func (g *GroupsList) Update(msg tea.Msg) (Component, tea.Cmd) {
 	var cmd tea.Cmd
 	g.Model, cmd = g.Model.Update(msg)
@@ -57,12 +71,28 @@ func (g *GroupsList) SetGroups(groups []provider.LogGroup) {
 	for _, group := range groups {
 		items = append(items, item{
 			title: group.Name,
-			desc:  group.Desc,
+			desc:  groupDesc(group),
 		})
 	}
 	g.SetItems(items)
 }
 
+// groupDesc follows a group's description with its retention and size,
+// when the backend reports them.
+func groupDesc(group provider.LogGroup) string {
+	parts := []string{}
+	if group.Desc != "" {
+		parts = append(parts, group.Desc)
+	}
+	if group.Retention > 0 {
+		parts = append(parts, fmt.Sprintf("%dd retention", int(group.Retention.Hours()/24)))
+	}
+	if group.StoredBytes > 0 {
+		parts = append(parts, provider.FormatBytes(group.StoredBytes))
+	}
+	return strings.Join(parts, " · ")
+}
+
 // StreamsList component
 type StreamsList struct {
 	list.Model
@@ -73,14 +103,24 @@ func NewStreamsList() *StreamsList {
 	delegate := list.NewDefaultDelegate()
 	delegate.ShowDescription = true
 	l := list.New([]list.Item{}, delegate, 0, 0)
-	l.AdditionalShortHelpKeys = func() []key.Binding {
-		return []key.Binding{
+	l.Paginator.Type = paginator.Arabic
+	s := &StreamsList{Model: l}
+	s.SetSaveEnabled(true)
+	return s
+}
+
+// SetSaveEnabled shows or hides the save binding in the help, for backends
+// whose streams can't be written out.
+func (s *StreamsList) SetSaveEnabled(enabled bool) {
+	s.AdditionalShortHelpKeys = func() []key.Binding {
+		keys := []key.Binding{
 			key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "toggle preview")),
-			key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "save stream")),
 		}
+		if enabled {
+			keys = append(keys, key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "save stream")))
+		}
+		return keys
 	}
-	l.Paginator.Type = paginator.Arabic
-	return &StreamsList{Model: l}
 }
 
 func (s *StreamsList) Update(msg tea.Msg) (Component, tea.Cmd) {
@@ -111,6 +151,12 @@ func (s *StreamsList) SetStreams(groupName string, streams []provider.LogStream)
 		if stream.LastEventTime != nil {
 			lastEvent = stream.LastEventTime.Format("2006-01-02 15:04:05")
 		}
+		if stream.FirstEventTime != nil && stream.LastEventTime != nil && !stream.FirstEventTime.Equal(*stream.LastEventTime) {
+			lastEvent = stream.FirstEventTime.Format("2006-01-02 15:04:05") + " → " + lastEvent
+		}
+		if stream.StoredBytes > 0 {
+			lastEvent += " · " + provider.FormatBytes(stream.StoredBytes)
+		}
 		
 		items = append(items, item{
 			title: stream.Name,
@@ -10,3 +10,9 @@ type Dependencies struct {
 	Profile string
 	Backend provider.Backend
 }
+
+// Capabilities reports what the backend supports, so states only offer
+// features the source has.
+func (d *Dependencies) Capabilities() provider.Capabilities {
+	return provider.CapabilitiesOf(d.Backend)
+}
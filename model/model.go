@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"strings"
@@ -81,17 +82,56 @@ type model struct {
 	previewEnabled    bool
 	initialGroup      string   // set via -g flag; when non-empty, TUI starts in StreamsState and esc quits
 	currentGroupName  string   // tracks the active group across state transitions
+	groupPattern      string   // server-side group filter in effect; esc clears it
 	streamFilter      string   // set via -s flag; applied once on first stream batch then cleared
 	errorText         string   // used by EventsState to show errors in footer
 	streamSaveStatus  string
 	streamSaving      bool     // prevents concurrent stream saves
+	fetches           map[fetchKind]context.CancelFunc
+}
+
+// fetchKind identifies a kind of background fetch. At most one of each kind
+// runs at a time, and states cancel the kinds they display on Exit.
+type fetchKind int
+
+const (
+	groupsFetch fetchKind = iota
+	streamsFetch
+	previewFetch
+	eventsFetch
+	eventsPollFetch
+)
+
+// startFetch cancels any running fetch of this kind and returns the context
+// for its replacement. One-shot requests pass the configured API timeout;
+// streaming loads pass 0, since a large stream legitimately outlasts any
+// fixed deadline and is bounded by cancellation instead.
+func (m *model) startFetch(kind fetchKind, timeout time.Duration) context.Context {
+	m.cancelFetch(kind)
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	m.fetches[kind] = cancel
+	return ctx
+}
+
+// cancelFetch stops the running fetch of this kind, if any.
+func (m *model) cancelFetch(kind fetchKind) {
+	if cancel, ok := m.fetches[kind]; ok {
+		cancel()
+		delete(m.fetches, kind)
+	}
 }
 
 func (m model) Init() tea.Cmd {
 	if m.initialGroup != "" {
-		return NewLoadStreamsAction(m.deps, m.initialGroup, m.streamFetchID).Execute()
+		return NewLoadStreamsAction(m.startFetch(streamsFetch, 0), m.deps, m.initialGroup, m.streamFetchID).Execute()
 	}
-	return NewLoadGroupsAction(m.deps).Execute()
+	return NewLoadGroupsAction(m.startFetch(groupsFetch, m.config.Timeouts.APITimeout), m.deps, "").Execute()
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -124,7 +164,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.eventsViewer.SetEvents(msg.events)
 		m.currentStreamName = msg.streamName
 		spinnerCmd := m.eventsViewer.StartLoading()
-		return m, tea.Batch(spinnerCmd, NewLoadEventsStreamingAction(m.deps, msg.groupName, msg.streamName).Execute())
+		return m, tea.Batch(spinnerCmd, NewLoadEventsStreamingAction(m.startFetch(eventsFetch, 0), m.deps, msg.groupName, msg.streamName).Execute())
 	case logEventPartialMsg:
 		m.eventsViewer.AppendEvents(msg.events)
 		if msg.nextCmd == nil {
@@ -180,7 +220,10 @@ func InitialModel(deps *Dependencies, group, streamFilter string) model {
 		deps:           deps,
 		config:         DefaultConfig(),
 		previewEnabled: true,
+		fetches:        map[fetchKind]context.CancelFunc{},
 	}
+	m.groupsList.SetServerSearch(deps.Capabilities().SupportsServerFilter())
+	m.streamsList.SetSaveEnabled(deps.Capabilities().SupportsWrite())
 	if group != "" {
 		m.initialGroup = group
 		m.currentGroupName = group
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"errors"
 	"os"
 	"path/filepath"
@@ -8,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/derricw/cwl/provider"
 )
@@ -333,3 +335,248 @@ func TestStreamsStateSaveLogsError(t *testing.T) {
 		t.Fatalf("expected save status to contain error, got %q", updated.streamSaveStatus)
 	}
 }
+
+// blockingBackend records the contexts it is called with. Its event stream
+// never ends on its own, so only cancellation stops it.
+type blockingBackend struct {
+	groupsCtx chan context.Context
+	stopped   chan error
+}
+
+func (b *blockingBackend) FetchGroups(ctx context.Context, pattern string) ([]provider.LogGroup, error) {
+	b.groupsCtx <- ctx
+	return nil, nil
+}
+
+func (b *blockingBackend) FetchStreamsStreaming(ctx context.Context, group string, callback func([]provider.LogStream) error) error {
+	return nil
+}
+
+func (b *blockingBackend) FetchEventsStreaming(ctx context.Context, group, stream string, callback func([]provider.LogEvent) error) error {
+	<-ctx.Done()
+	b.stopped <- ctx.Err()
+	return ctx.Err()
+}
+
+func (b *blockingBackend) FetchLastEvents(ctx context.Context, group, stream string, limit int) ([]provider.LogEvent, error) {
+	return nil, nil
+}
+
+func (b *blockingBackend) FetchNewEvents(ctx context.Context, group, stream string, since *time.Time) ([]provider.LogEvent, error) {
+	return nil, nil
+}
+
+// TestEventsStateExitCancelsLoad verifies that leaving the events view
+// cancels the stream load still running in the background.
+func TestEventsStateExitCancelsLoad(t *testing.T) {
+	backend := &blockingBackend{stopped: make(chan error, 1)}
+	m := InitialModel(&Dependencies{Backend: backend}, "", "")
+	m.state = &EventsState{}
+
+	newModel, _ := m.Update(logEventMsg{groupName: "/aws/test", streamName: "s"})
+	m = newModel.(model)
+	select {
+	case err := <-backend.stopped:
+		t.Fatalf("load stopped before exit: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if _, ok := newModel.(model).state.(*StreamsState); !ok {
+		t.Fatalf("expected StreamsState, got %T", newModel.(model).state)
+	}
+	select {
+	case err := <-backend.stopped:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("load was not cancelled on exit")
+	}
+}
+
+// TestLoadGroupsHonorsAPITimeout verifies that one-shot fetches carry a
+// deadline from Config.Timeouts.APITimeout.
+func TestLoadGroupsHonorsAPITimeout(t *testing.T) {
+	backend := &blockingBackend{groupsCtx: make(chan context.Context, 1)}
+	m := InitialModel(&Dependencies{Backend: backend}, "", "")
+	m.config.Timeouts.APITimeout = time.Minute
+
+	start := time.Now()
+	m.Init()()
+	ctx := <-backend.groupsCtx
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("expected a deadline")
+	}
+	if d := deadline.Sub(start); d < 59*time.Second || d > time.Minute+time.Second {
+		t.Errorf("deadline %v from start, want about 1m", d)
+	}
+}
+
+// staticBackend is a backend whose data never changes once listed.
+type staticBackend struct {
+	blockingBackend
+}
+
+func (staticBackend) SupportsFollow() bool       { return false }
+func (staticBackend) SupportsQuery() bool        { return false }
+func (staticBackend) SupportsServerFilter() bool { return false }
+func (staticBackend) SupportsWrite() bool        { return true }
+
+// TestEventsStateFollowsOnlyWhenSupported verifies that the events view only
+// starts its polling ticker, and only advertises following, for backends
+// that can produce new events.
+func TestEventsStateFollowsOnlyWhenSupported(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		backend provider.Backend
+		follow  bool
+	}{
+		{"default", &blockingBackend{}, true},
+		{"static", &staticBackend{}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := InitialModel(&Dependencies{Backend: tc.backend}, "", "")
+			s := &EventsState{}
+			if cmd := s.Enter(&m); (cmd != nil) != tc.follow {
+				t.Errorf("Enter returned tick %v, want %v", cmd != nil, tc.follow)
+			}
+			if got := strings.Contains(s.View(&m), "following"); got != tc.follow {
+				t.Errorf("footer mentions following = %v, want %v", got, tc.follow)
+			}
+			if cmd := (&StreamsState{}).Enter(&m); (cmd != nil) != tc.follow {
+				t.Errorf("StreamsState.Enter returned tick %v, want %v", cmd != nil, tc.follow)
+			}
+		})
+	}
+}
+
+// TestGroupDescShowsRetentionAndSize verifies that the groups list shows
+// retention and size only when the backend reports them.
+func TestGroupDescShowsRetentionAndSize(t *testing.T) {
+	for _, tc := range []struct {
+		group provider.LogGroup
+		want  string
+	}{
+		{provider.LogGroup{Desc: "arn"}, "arn"},
+		{provider.LogGroup{Desc: "arn", Retention: 30 * 24 * time.Hour, StoredBytes: 1536}, "arn · 30d retention · 1.5KB"},
+		{provider.LogGroup{StoredBytes: 10}, "10B"},
+	} {
+		if got := groupDesc(tc.group); got != tc.want {
+			t.Errorf("groupDesc(%+v) = %q, want %q", tc.group, got, tc.want)
+		}
+	}
+}
+
+// searchBackend filters groups on the server and records the patterns it
+// is asked for.
+type searchBackend struct {
+	staticBackend
+	patterns chan string
+}
+
+func (b *searchBackend) FetchGroups(ctx context.Context, pattern string) ([]provider.LogGroup, error) {
+	b.patterns <- pattern
+	return nil, nil
+}
+
+func (searchBackend) SupportsServerFilter() bool { return true }
+
+// TestGroupsStateServerSearch verifies that accepting the filter asks a
+// backend that filters on the server for matching groups, and that esc
+// clears the search and lists every group again.
+func TestGroupsStateServerSearch(t *testing.T) {
+	backend := &searchBackend{patterns: make(chan string, 1)}
+	m := InitialModel(&Dependencies{Backend: backend}, "", "")
+	m.groupsList.SetSize(80, 20)
+	m.groupsList.SetGroups([]provider.LogGroup{{Name: "/aws/lambda/api"}})
+
+	for _, msg := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("/")},
+		{Type: tea.KeyRunes, Runes: []rune("api")},
+	} {
+		newModel, _ := m.Update(msg)
+		m = newModel.(model)
+	}
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(model)
+	if cmd == nil {
+		t.Fatal("expected a group search command")
+	}
+	runCmd(cmd)
+	if got := <-backend.patterns; got != "api" {
+		t.Errorf("searched for %q, want %q", got, "api")
+	}
+	if _, ok := m.state.(*GroupsState); !ok {
+		t.Fatalf("expected GroupsState, got %T", m.state)
+	}
+
+	newModel, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = newModel.(model)
+	if m.groupPattern != "" {
+		t.Errorf("groupPattern = %q after esc, want empty", m.groupPattern)
+	}
+	if cmd == nil {
+		t.Fatal("expected esc to reload groups")
+	}
+	cmd()
+	if got := <-backend.patterns; got != "" {
+		t.Errorf("reloaded with %q, want all groups", got)
+	}
+}
+
+// runCmd runs cmd and, for a batch, each command in it.
+func runCmd(cmd tea.Cmd) {
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		for _, c := range batch {
+			if c != nil {
+				runCmd(c)
+			}
+		}
+	}
+}
+
+// readOnlyBackend is a backend whose streams can't be saved.
+type readOnlyBackend struct {
+	staticBackend
+}
+
+func (readOnlyBackend) SupportsWrite() bool { return false }
+
+// TestSaveDisabledWithoutWrite verifies that the save binding does nothing,
+// and isn't advertised, for backends that can't write streams out.
+func TestSaveDisabledWithoutWrite(t *testing.T) {
+	m := InitialModel(&Dependencies{Backend: &readOnlyBackend{}}, "", "")
+	m.streamsList.SetSize(80, 20)
+	m.streamsList.SetItems([]list.Item{item{title: "stream-1"}})
+	save := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(m.config.KeyBinds.SaveLogs)}
+
+	m.state = &StreamsState{}
+	newModel, cmd := m.Update(save)
+	m = newModel.(model)
+	if cmd != nil {
+		t.Error("save in streams view returned a command")
+	}
+	if m.streamSaving || m.streamSaveStatus != "" {
+		t.Errorf("save in streams view started saving: %q", m.streamSaveStatus)
+	}
+	if strings.Contains(m.streamsList.Help.View(m.streamsList.Model), "save") {
+		t.Error("streams help advertises saving")
+	}
+
+	s := &EventsState{}
+	m.state = s
+	newModel, cmd = m.Update(save)
+	m = newModel.(model)
+	if cmd != nil {
+		t.Error("save in events view returned a command")
+	}
+	if s.saveStatus != "" {
+		t.Errorf("save in events view set status %q", s.saveStatus)
+	}
+	if strings.Contains(s.View(&m), "save") {
+		t.Error("events footer advertises saving")
+	}
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -37,8 +38,22 @@ func (s *GroupsState) Update(msg tea.Msg, m *model) (State, tea.Cmd) {
 		case m.config.KeyBinds.Quit:
 			return s, tea.Quit
 		case m.config.KeyBinds.Back:
+			if m.groupPattern != "" && !m.groupsList.Model.SettingFilter() {
+				m.groupPattern = ""
+				m.groupsList.Model.ResetFilter()
+				return s, NewLoadGroupsAction(m.startFetch(groupsFetch, m.config.Timeouts.APITimeout), m.deps, "").Execute()
+			}
 			return s, nil
 		case m.config.KeyBinds.Select:
+			// Backends that filter on the server are asked for the groups
+			// matching the typed filter, which finds groups beyond the first
+			// listing; the local filter then stays on over the results.
+			if m.groupsList.Model.SettingFilter() && m.deps.Capabilities().SupportsServerFilter() {
+				m.groupPattern = m.groupsList.Model.FilterValue()
+				comp, cmd := m.groupsList.Update(msg)
+				m.groupsList = comp.(*GroupsList)
+				return s, tea.Batch(cmd, NewLoadGroupsAction(m.startFetch(groupsFetch, m.config.Timeouts.APITimeout), m.deps, m.groupPattern).Execute())
+			}
 			// Guard against nil SelectedItem — list returns nil when empty (e.g. no credentials)
 			if !m.groupsList.Model.SettingFilter() {
 				if selected := m.groupsList.Model.SelectedItem(); selected != nil {
@@ -46,7 +61,7 @@ func (s *GroupsState) Update(msg tea.Msg, m *model) (State, tea.Cmd) {
 					m.currentGroupName = groupName
 					m.logStreams = nil // clear stale streams from previous group
 					m.streamFetchID++
-					return &StreamsState{}, NewLoadStreamsAction(m.deps, groupName, m.streamFetchID).Execute()
+					return &StreamsState{}, NewLoadStreamsAction(m.startFetch(streamsFetch, 0), m.deps, groupName, m.streamFetchID).Execute()
 				}
 			}
 		}
@@ -61,7 +76,9 @@ func (s *GroupsState) View(m *model) string {
 	return m.config.Styles.DocStyle.Render(m.groupsList.View())
 }
 
+// Enter abandons the stream listing of the group being left.
 func (s *GroupsState) Enter(m *model) tea.Cmd {
+	m.cancelFetch(streamsFetch)
 	return nil
 }
 
@@ -79,7 +96,7 @@ func (s *StreamsState) checkPreview(m *model) tea.Cmd {
 			m.previewStream = name
 			m.previewContent = ""
 			m.previewFetchID++
-			return NewLoadPreviewEventsAction(m.deps, m.currentGroupName, name, m.previewFetchID).Execute()
+			return NewLoadPreviewEventsAction(m.startFetch(previewFetch, m.config.Timeouts.APITimeout), m.deps, m.currentGroupName, name, m.previewFetchID).Execute()
 		}
 	}
 	return nil
@@ -109,7 +126,7 @@ func (s *StreamsState) Update(msg tea.Msg, m *model) (State, tea.Cmd) {
 		// periodically check for new streams
 		m.logStreams = nil
 		m.streamFetchID++
-		return s, tea.Batch(NewLoadStreamsAction(m.deps, m.currentGroupName, m.streamFetchID).Execute(), s.tickCmd())
+		return s, tea.Batch(NewLoadStreamsAction(m.startFetch(streamsFetch, 0), m.deps, m.currentGroupName, m.streamFetchID).Execute(), s.tickCmd())
 	case tea.KeyMsg:
 		switch msg.String() {
 		case m.config.KeyBinds.Quit:
@@ -144,6 +161,9 @@ func (s *StreamsState) Update(msg tea.Msg, m *model) (State, tea.Cmd) {
 				return s, nil
 			}
 		case m.config.KeyBinds.SaveLogs:
+			if !m.streamsList.Model.SettingFilter() && !m.deps.Capabilities().SupportsWrite() {
+				return s, nil
+			}
 			if !m.streamsList.Model.SettingFilter() && !m.streamSaving {
 				if streamItem := m.streamsList.Model.SelectedItem(); streamItem != nil {
 					streamName := streamItem.(item).Title()
@@ -194,11 +214,17 @@ func (s *StreamsState) View(m *model) string {
 	)
 }
 
+// Enter starts the stream refresh ticker, unless the backend's streams can't
+// change while browsing.
 func (s *StreamsState) Enter(m *model) tea.Cmd {
+	if !m.deps.Capabilities().SupportsFollow() {
+		return nil
+	}
 	return s.tickCmd()
 }
 
 func (s *StreamsState) Exit(m *model) {
+	m.cancelFetch(previewFetch)
 	m.previewStream = ""
 	m.previewContent = ""
 }
@@ -234,7 +260,7 @@ func (s *EventsState) Update(msg tea.Msg, m *model) (State, tea.Cmd) {
 	case tickMsg:
 		if !m.eventsViewer.IsLoading() {
 			lastTime := m.eventsViewer.GetLastEventTime()
-			cmds = append(cmds, NewPollNewEventsAction(m.deps, s.groupName, s.streamName, lastTime).Execute())
+			cmds = append(cmds, NewPollNewEventsAction(m.startFetch(eventsPollFetch, m.config.Timeouts.APITimeout), m.deps, s.groupName, s.streamName, lastTime).Execute())
 		}
 		return s, tea.Batch(append(cmds, s.tickCmd())...)
 	case tea.KeyMsg:
@@ -285,6 +311,9 @@ func (s *EventsState) Update(msg tea.Msg, m *model) (State, tea.Cmd) {
 					return s, nil
 				}
 			case m.config.KeyBinds.SaveLogs:
+				if !m.deps.Capabilities().SupportsWrite() {
+					return s, nil
+				}
 				if !m.eventsViewer.loading {
 					s.saveStatus = "Saving..."
 					return s, saveLogsCmd(m.eventsViewer.rawEvents)
@@ -316,7 +345,13 @@ func (s *EventsState) View(m *model) string {
 	if m.eventsViewer.IsFiltering() {
 		footerText += " | ESC/Enter to exit filter"
 	} else {
-		footerText += " | / to filter | t timestamps | w wrap | s save"
+		footerText += " | / to filter | t timestamps | w wrap"
+		if m.deps.Capabilities().SupportsWrite() {
+			footerText += " | s save"
+		}
+	}
+	if m.deps.Capabilities().SupportsFollow() {
+		footerText += " | following"
 	}
 	if s.saveStatus != "" {
 		footerText += " | " + s.saveStatus
@@ -336,6 +371,10 @@ func (s *EventsState) Enter(m *model) tea.Cmd {
 	if streamItem := m.streamsList.Model.SelectedItem(); streamItem != nil {
 		s.streamName = streamItem.(item).Title()
 	}
+	// only poll for new events when the backend can produce them
+	if !m.deps.Capabilities().SupportsFollow() {
+		return nil
+	}
 	return s.tickCmd()
 }
 
@@ -346,6 +385,8 @@ func (s *EventsState) tickCmd() tea.Cmd {
 }
 
 func (s *EventsState) Exit(m *model) {
+	m.cancelFetch(eventsFetch)
+	m.cancelFetch(eventsPollFetch)
 }
 
 // saveLogsCmd writes already-loaded events from the events viewer to disk.
@@ -378,6 +419,7 @@ func saveLogsCmd(events []provider.LogEvent) tea.Cmd {
 // directly to disk. Unlike saveLogsCmd, this streams events without loading
 // them all into memory, so it works for arbitrarily large streams.
 // Used in StreamsState to save a stream without opening the events view.
+// The save is not tied to any state, so it finishes even if the user moves on.
 func saveStreamCmd(deps *Dependencies, groupName, streamName string) tea.Cmd {
 	return func() tea.Msg {
 		home, err := os.UserHomeDir()
@@ -395,7 +437,7 @@ func saveStreamCmd(deps *Dependencies, groupName, streamName string) tea.Cmd {
 			return saveLogsMsg{err: err}
 		}
 		defer f.Close()
-		err = deps.Backend.FetchEventsStreaming(groupName, streamName, func(events []provider.LogEvent) error {
+		err = deps.Backend.FetchEventsStreaming(context.Background(), groupName, streamName, func(events []provider.LogEvent) error {
 			for _, e := range events {
 				msg := strings.TrimRight(e.Message, "\r\n")
 				if _, err := f.WriteString(msg + "\n"); err != nil {
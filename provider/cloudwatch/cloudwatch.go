@@ -2,8 +2,11 @@
 package cloudwatch
 
 import (
+	"context"
+	"net/url"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 	"github.com/derricw/cwl/fetch"
 	"github.com/derricw/cwl/interfaces"
@@ -14,6 +17,28 @@ type Backend struct {
 	Client interfaces.CloudWatchLogsClient
 }
 
+func init() {
+	provider.Register("cloudwatch", open)
+}
+
+// open handles cloudwatch://[profile@][region]. Both parts are optional and
+// fall back to --profile and the profile's configured region.
+func open(uri string, opts provider.OpenOptions) (provider.Backend, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	profile := opts.Profile
+	if name := u.User.Username(); name != "" {
+		profile = name
+	}
+	client, _, err := fetch.CreateRegionalClient(profile, u.Host)
+	if err != nil {
+		return nil, err
+	}
+	return &Backend{Client: client}, nil
+}
+
 func New(profile string) (*Backend, error) {
 	client, err := fetch.CreateClient(profile)
 	if err != nil {
@@ -22,71 +47,112 @@ func New(profile string) (*Backend, error) {
 	return &Backend{Client: client}, nil
 }
 
-func (b *Backend) FetchGroups(pattern string) ([]provider.LogGroup, error) {
-	groups, err := fetch.FetchLogGroups(b.Client, pattern)
+func (b *Backend) FetchGroups(ctx context.Context, pattern string) ([]provider.LogGroup, error) {
+	groups, err := fetch.FetchLogGroups(ctx, b.Client, pattern)
 	if err != nil {
 		return nil, err
 	}
 	result := make([]provider.LogGroup, len(groups))
 	for i, g := range groups {
-		result[i] = provider.LogGroup{
-			Name: *g.LogGroupName,
-			Desc: *g.LogGroupArn,
-		}
+		result[i] = convertGroup(g)
 	}
 	return result, nil
 }
 
-func (b *Backend) FetchStreamsStreaming(group string, callback func([]provider.LogStream) error) error {
-	return fetch.FetchLogStreamsStreaming(b.Client, group, func(streams []types.LogStream) error {
+func (b *Backend) FetchStreamsStreaming(ctx context.Context, group string, callback func([]provider.LogStream) error) error {
+	return fetch.FetchLogStreamsStreaming(ctx, b.Client, group, func(streams []types.LogStream) error {
 		converted := make([]provider.LogStream, len(streams))
 		for i, s := range streams {
-			converted[i] = provider.LogStream{Name: *s.LogStreamName}
-			if s.LastEventTimestamp != nil {
-				t := time.Unix(0, *s.LastEventTimestamp*int64(time.Millisecond))
-				converted[i].LastEventTime = &t
+			converted[i] = provider.LogStream{
+				Name:           *s.LogStreamName,
+				FirstEventTime: millis(s.FirstEventTimestamp),
+				LastEventTime:  millis(s.LastEventTimestamp),
+				StoredBytes:    aws.ToInt64(s.StoredBytes),
+				ARN:            aws.ToString(s.Arn),
 			}
 		}
 		return callback(converted)
 	})
 }
 
-func (b *Backend) FetchEventsStreaming(group, stream string, callback func([]provider.LogEvent) error) error {
-	return fetch.FetchLogEventsStreaming(b.Client, group, stream, func(events []types.OutputLogEvent) error {
-		return callback(convertEvents(events))
+func (b *Backend) FetchEventsStreaming(ctx context.Context, group, stream string, callback func([]provider.LogEvent) error) error {
+	return fetch.FetchLogEventsStreaming(ctx, b.Client, group, stream, func(events []types.OutputLogEvent) error {
+		return callback(convertEvents(stream, events))
 	})
 }
 
-func (b *Backend) FetchLastEvents(group, stream string, limit int) ([]provider.LogEvent, error) {
-	events, err := fetch.FetchLastLogEvents(b.Client, group, stream, int32(limit))
+func (b *Backend) FetchLastEvents(ctx context.Context, group, stream string, limit int) ([]provider.LogEvent, error) {
+	events, err := fetch.FetchLastLogEvents(ctx, b.Client, group, stream, int32(limit))
 	if err != nil {
 		return nil, err
 	}
-	return convertEvents(events), nil
+	return convertEvents(stream, events), nil
 }
 
-func (b *Backend) FetchNewEvents(group, stream string, since *time.Time) ([]provider.LogEvent, error) {
+func (b *Backend) FetchNewEvents(ctx context.Context, group, stream string, since *time.Time) ([]provider.LogEvent, error) {
 	if since == nil {
 		return nil, nil
 	}
 	sinceMs := since.UnixMilli()
-	events, err := fetch.FetchNewLogEvents(b.Client, group, stream, &sinceMs)
+	events, err := fetch.FetchNewLogEvents(ctx, b.Client, group, stream, &sinceMs)
 	if err != nil {
 		return nil, err
 	}
-	return convertEvents(events), nil
+	return convertEvents(stream, events), nil
 }
 
-func convertEvents(events []types.OutputLogEvent) []provider.LogEvent {
+// Capabilities: CloudWatch supports everything cwl can do.
+
+func (b *Backend) SupportsFollow() bool       { return true }
+func (b *Backend) SupportsQuery() bool        { return true }
+func (b *Backend) SupportsServerFilter() bool { return true }
+func (b *Backend) SupportsWrite() bool        { return true }
+
+// convertGroup keeps what DescribeLogGroups reports, so Tags stay nil; see
+// provider.LogGroup.
+func convertGroup(g types.LogGroup) provider.LogGroup {
+	group := provider.LogGroup{
+		Name:        aws.ToString(g.LogGroupName),
+		Desc:        aws.ToString(g.LogGroupArn),
+		StoredBytes: aws.ToInt64(g.StoredBytes),
+		CreatedTime: millis(g.CreationTime),
+		Metadata:    map[string]string{},
+	}
+	if g.RetentionInDays != nil {
+		group.Retention = time.Duration(*g.RetentionInDays) * 24 * time.Hour
+	}
+	if g.LogGroupClass != "" {
+		group.Metadata["class"] = string(g.LogGroupClass)
+	}
+	if g.KmsKeyId != nil {
+		group.Metadata["kms_key_id"] = *g.KmsKeyId
+	}
+	if g.DataProtectionStatus != "" {
+		group.Metadata["data_protection"] = string(g.DataProtectionStatus)
+	}
+	return group
+}
+
+// convertEvents maps GetLogEvents results, which all come from stream.
+// They carry no event ID; only FilterLogEvents returns one.
+func convertEvents(stream string, events []types.OutputLogEvent) []provider.LogEvent {
 	result := make([]provider.LogEvent, len(events))
 	for i, e := range events {
-		if e.Message != nil {
-			result[i].Message = *e.Message
-		}
-		if e.Timestamp != nil {
-			t := time.Unix(0, *e.Timestamp*int64(time.Millisecond))
-			result[i].Timestamp = &t
+		result[i] = provider.LogEvent{
+			Message:       aws.ToString(e.Message),
+			Timestamp:     millis(e.Timestamp),
+			IngestionTime: millis(e.IngestionTime),
+			Stream:        stream,
 		}
 	}
 	return result
 }
+
+// millis converts an optional Unix millisecond timestamp.
+func millis(ms *int64) *time.Time {
+	if ms == nil {
+		return nil
+	}
+	t := time.UnixMilli(*ms)
+	return &t
+}
@@ -0,0 +1,166 @@
+// Package composite implements provider.Backend over several named sources,
+// so one TUI can browse e.g. prod and staging accounts side by side.
+//
+// Group names are namespaced as "<source>:<group>" and routed back to the
+// source they came from; stream names are passed through unchanged.
+package composite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/derricw/cwl/provider"
+)
+
+// separator joins a source name to a group name.
+const separator = ":"
+
+// Source is a backend and the name its groups are listed under.
+type Source struct {
+	Name    string
+	Backend provider.Backend
+}
+
+type Backend struct {
+	sources []Source
+	byName  map[string]provider.Backend
+}
+
+// New creates a backend over sources, in the order their groups are listed.
+// Names must be unique, non-empty and free of the ":" separator.
+func New(sources ...Source) (*Backend, error) {
+	b := &Backend{sources: sources, byName: map[string]provider.Backend{}}
+	for _, s := range sources {
+		if s.Name == "" || strings.Contains(s.Name, separator) {
+			return nil, fmt.Errorf("invalid source name %q: must be non-empty and not contain %q", s.Name, separator)
+		}
+		if _, dup := b.byName[s.Name]; dup {
+			return nil, fmt.Errorf("duplicate source name %q", s.Name)
+		}
+		b.byName[s.Name] = s.Backend
+	}
+	return b, nil
+}
+
+// Backend interface implementation
+
+// FetchGroups asks every source at once. Groups from the sources that
+// answered are returned even when others fail, together with an error
+// naming the failures, so one unreachable account doesn't hide the rest.
+func (b *Backend) FetchGroups(ctx context.Context, pattern string) ([]provider.LogGroup, error) {
+	results := make([][]provider.LogGroup, len(b.sources))
+	errs := make([]error, len(b.sources))
+	var wg sync.WaitGroup
+	for i, s := range b.sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			groups, err := s.Backend.FetchGroups(ctx, pattern)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", s.Name, err)
+				return
+			}
+			for j := range groups {
+				groups[j].Name = s.Name + separator + groups[j].Name
+				groups[j].Desc = describe(s.Name, groups[j].Desc)
+			}
+			results[i] = groups
+		}()
+	}
+	wg.Wait()
+
+	var all []provider.LogGroup
+	for _, groups := range results {
+		all = append(all, groups...)
+	}
+	return all, errors.Join(errs...)
+}
+
+func (b *Backend) FetchStreamsStreaming(ctx context.Context, group string, callback func([]provider.LogStream) error) error {
+	child, name, err := b.route(group)
+	if err != nil {
+		return err
+	}
+	return child.FetchStreamsStreaming(ctx, name, callback)
+}
+
+func (b *Backend) FetchEventsStreaming(ctx context.Context, group, stream string, callback func([]provider.LogEvent) error) error {
+	child, name, err := b.route(group)
+	if err != nil {
+		return err
+	}
+	return child.FetchEventsStreaming(ctx, name, stream, callback)
+}
+
+func (b *Backend) FetchLastEvents(ctx context.Context, group, stream string, limit int) ([]provider.LogEvent, error) {
+	child, name, err := b.route(group)
+	if err != nil {
+		return nil, err
+	}
+	return child.FetchLastEvents(ctx, name, stream, limit)
+}
+
+func (b *Backend) FetchNewEvents(ctx context.Context, group, stream string, since *time.Time) ([]provider.LogEvent, error) {
+	child, name, err := b.route(group)
+	if err != nil {
+		return nil, err
+	}
+	return child.FetchNewEvents(ctx, name, stream, since)
+}
+
+// Capabilities: following is enabled if any source can follow, since
+// polling a static source just returns nothing. Groups are only filtered on
+// the server if every source can.
+
+func (b *Backend) SupportsFollow() bool {
+	return b.any(provider.Capabilities.SupportsFollow)
+}
+
+func (b *Backend) SupportsQuery() bool {
+	return !b.any(func(c provider.Capabilities) bool { return !c.SupportsQuery() })
+}
+
+func (b *Backend) SupportsServerFilter() bool {
+	return !b.any(func(c provider.Capabilities) bool { return !c.SupportsServerFilter() })
+}
+
+func (b *Backend) SupportsWrite() bool {
+	return !b.any(func(c provider.Capabilities) bool { return !c.SupportsWrite() })
+}
+
+// Helpers
+
+func (b *Backend) any(f func(provider.Capabilities) bool) bool {
+	for _, s := range b.sources {
+		if f(provider.CapabilitiesOf(s.Backend)) {
+			return true
+		}
+	}
+	return false
+}
+
+// route splits a namespaced group into its source backend and the group
+// name that source knows it by.
+func (b *Backend) route(group string) (provider.Backend, string, error) {
+	source, name, ok := strings.Cut(group, separator)
+	if !ok {
+		return nil, "", fmt.Errorf("group %q has no source prefix", group)
+	}
+	child, ok := b.byName[source]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown source %q in group %q", source, group)
+	}
+	return child, name, nil
+}
+
+// describe prefixes a group's description with its source.
+func describe(source, desc string) string {
+	if desc == "" {
+		return "[" + source + "]"
+	}
+	return "[" + source + "] " + desc
+}
@@ -0,0 +1,150 @@
+package composite
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/derricw/cwl/provider"
+)
+
+// fakeBackend serves fixed groups and records the names it is asked for.
+type fakeBackend struct {
+	groups []provider.LogGroup
+	err    error
+	follow bool
+	query  bool
+	write  bool
+	// serverFilter is whether FetchGroups claims to match on the server
+	serverFilter bool
+	asked        []string
+}
+
+func (f *fakeBackend) FetchGroups(ctx context.Context, pattern string) ([]provider.LogGroup, error) {
+	return append([]provider.LogGroup(nil), f.groups...), f.err
+}
+
+func (f *fakeBackend) FetchStreamsStreaming(ctx context.Context, group string, callback func([]provider.LogStream) error) error {
+	f.asked = append(f.asked, group)
+	return callback([]provider.LogStream{{Name: "s"}})
+}
+
+func (f *fakeBackend) FetchEventsStreaming(ctx context.Context, group, stream string, callback func([]provider.LogEvent) error) error {
+	f.asked = append(f.asked, group+"/"+stream)
+	return nil
+}
+
+func (f *fakeBackend) FetchLastEvents(ctx context.Context, group, stream string, limit int) ([]provider.LogEvent, error) {
+	f.asked = append(f.asked, group+"/"+stream)
+	return nil, nil
+}
+
+func (f *fakeBackend) FetchNewEvents(ctx context.Context, group, stream string, since *time.Time) ([]provider.LogEvent, error) {
+	f.asked = append(f.asked, group+"/"+stream)
+	return nil, nil
+}
+
+func (f *fakeBackend) SupportsFollow() bool       { return f.follow }
+func (f *fakeBackend) SupportsQuery() bool        { return f.query }
+func (f *fakeBackend) SupportsServerFilter() bool { return f.serverFilter }
+func (f *fakeBackend) SupportsWrite() bool        { return f.write }
+
+// TestFetchGroupsNamespacesAndRoutes verifies that groups are listed under
+// their source's name and that stream and event calls reach that source
+// with the original group name.
+func TestFetchGroupsNamespacesAndRoutes(t *testing.T) {
+	prod := &fakeBackend{groups: []provider.LogGroup{{Name: "/aws/lambda/api", Desc: "7 days"}}}
+	staging := &fakeBackend{groups: []provider.LogGroup{{Name: "/aws/lambda/api"}}}
+	b, err := New(Source{"prod", prod}, Source{"staging", staging})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := b.FetchGroups(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []provider.LogGroup{
+		{Name: "prod:/aws/lambda/api", Desc: "[prod] 7 days"},
+		{Name: "staging:/aws/lambda/api", Desc: "[staging]"},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("got %d groups, want %d", len(groups), len(want))
+	}
+	for i := range want {
+		if groups[i].Name != want[i].Name || groups[i].Desc != want[i].Desc {
+			t.Errorf("group %d = %+v, want %+v", i, groups[i], want[i])
+		}
+	}
+
+	ctx := context.Background()
+	if err := b.FetchStreamsStreaming(ctx, "staging:/aws/lambda/api", func([]provider.LogStream) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.FetchLastEvents(ctx, "staging:/aws/lambda/api", "s", 10); err != nil {
+		t.Fatal(err)
+	}
+	if len(prod.asked) != 0 {
+		t.Errorf("prod was asked for %v", prod.asked)
+	}
+	if got := strings.Join(staging.asked, ","); got != "/aws/lambda/api,/aws/lambda/api/s" {
+		t.Errorf("staging was asked for %q", got)
+	}
+
+	if _, err := b.FetchLastEvents(ctx, "dev:/x", "s", 10); err == nil {
+		t.Error("expected an error for an unknown source")
+	}
+}
+
+// TestFetchGroupsPartialFailure verifies that one failing source doesn't
+// hide the groups of the others, and that its error is reported by name.
+func TestFetchGroupsPartialFailure(t *testing.T) {
+	b, err := New(
+		Source{"ok", &fakeBackend{groups: []provider.LogGroup{{Name: "g"}}}},
+		Source{"down", &fakeBackend{err: errors.New("connection refused")}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups, err := b.FetchGroups(context.Background(), "")
+	if len(groups) != 1 || groups[0].Name != "ok:g" {
+		t.Errorf("groups = %+v, want ok:g", groups)
+	}
+	if err == nil || !strings.Contains(err.Error(), "down: connection refused") {
+		t.Errorf("err = %v, want it to name the failing source", err)
+	}
+}
+
+// TestNewRejectsBadNames verifies that source names must be unique and
+// unambiguous in a namespaced group name.
+func TestNewRejectsBadNames(t *testing.T) {
+	for _, sources := range [][]Source{
+		{{Name: "", Backend: &fakeBackend{}}},
+		{{Name: "a:b", Backend: &fakeBackend{}}},
+		{{Name: "a", Backend: &fakeBackend{}}, {Name: "a", Backend: &fakeBackend{}}},
+	} {
+		if _, err := New(sources...); err == nil {
+			t.Errorf("New(%+v) succeeded, want an error", sources)
+		}
+	}
+}
+
+// TestCapabilities verifies that following is on if any source follows,
+// while querying, server-side filtering and saving need every source.
+func TestCapabilities(t *testing.T) {
+	b, _ := New(Source{"a", &fakeBackend{follow: true, query: true, serverFilter: true, write: true}}, Source{"b", &fakeBackend{write: true}})
+	if !b.SupportsFollow() {
+		t.Error("SupportsFollow = false, want true")
+	}
+	if b.SupportsServerFilter() {
+		t.Error("SupportsServerFilter = true, want false")
+	}
+	if b.SupportsQuery() {
+		t.Error("SupportsQuery = true, want false")
+	}
+	if !b.SupportsWrite() {
+		t.Error("SupportsWrite = false, want true")
+	}
+}
@@ -0,0 +1,431 @@
+// Package docker implements provider.Backend for the logs Docker's json-file
+// driver writes, read straight from disk so no daemon is needed.
+// Maps: containers → groups, stdout/stderr → streams, log entries → events.
+//
+// Each container directory under the root (/var/lib/docker/containers by
+// default) holds <id>-json.log, rotated copies (<id>-json.log.1, ...) when
+// max-file is set, and config.v2.json, which names the container.
+package docker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/derricw/cwl/provider"
+)
+
+// DefaultRoot is where the Docker daemon keeps container state on Linux.
+const DefaultRoot = "/var/lib/docker/containers"
+
+// batchSize is the number of events passed to each streaming callback.
+const batchSize = 1000
+
+// streams are the streams every container has.
+var streams = []string{"stdout", "stderr"}
+
+type Backend struct {
+	root string
+	// offsets records how far each container's current log has been read
+	// per stream, so FetchNewEvents returns only entries appended since.
+	mu      sync.Mutex
+	offsets map[string]position
+}
+
+// position is how far into a log file reading has got. info identifies the
+// file, so a rotation is noticed even when the new log has already grown
+// past the old offset.
+type position struct {
+	offset int64
+	info   os.FileInfo
+}
+
+func init() {
+	provider.Register("docker", open)
+}
+
+// open handles docker:// (the default root) and docker:///path/to/containers.
+func open(uri string, opts provider.OpenOptions) (provider.Backend, error) {
+	_, root, _ := strings.Cut(uri, "://")
+	if root == "" {
+		root = DefaultRoot
+	}
+	return New(root)
+}
+
+// New creates a backend reading the container directories under root.
+func New(root string) (*Backend, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	return &Backend{root: filepath.Clean(root), offsets: map[string]position{}}, nil
+}
+
+// container is a container directory with a json-file log.
+type container struct {
+	ID    string
+	Name  string
+	Image string
+}
+
+// containerConfig is the part of config.v2.json cwl reads.
+type containerConfig struct {
+	Name   string `json:"Name"`
+	Config struct {
+		Image string `json:"Image"`
+	} `json:"Config"`
+}
+
+// Backend interface implementation
+
+func (b *Backend) FetchGroups(ctx context.Context, pattern string) ([]provider.LogGroup, error) {
+	containers, err := b.containers()
+	if err != nil {
+		return nil, err
+	}
+	var groups []provider.LogGroup
+	for _, c := range containers {
+		if pattern != "" && !strings.Contains(strings.ToLower(c.Name), strings.ToLower(pattern)) {
+			continue
+		}
+		desc := shortID(c.ID)
+		if c.Image != "" {
+			desc = c.Image + " " + desc
+		}
+		groups = append(groups, provider.LogGroup{Name: c.Name, Desc: desc})
+	}
+	return groups, nil
+}
+
+// FetchStreamsStreaming lists stdout and stderr. Finding out which of them a
+// container actually wrote to would mean reading its whole log, so both are
+// listed with the log's modification time.
+func (b *Backend) FetchStreamsStreaming(ctx context.Context, group string, callback func([]provider.LogStream) error) error {
+	c, err := b.container(group)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(b.logPath(c.ID))
+	if err != nil {
+		return err
+	}
+	modTime := info.ModTime()
+	result := make([]provider.LogStream, len(streams))
+	for i, s := range streams {
+		result[i] = provider.LogStream{Name: s, LastEventTime: &modTime}
+	}
+	return callback(result)
+}
+
+func (b *Backend) FetchEventsStreaming(ctx context.Context, group, stream string, callback func([]provider.LogEvent) error) error {
+	c, err := b.container(group)
+	if err != nil {
+		return err
+	}
+	if err := checkStream(stream); err != nil {
+		return err
+	}
+	// rotated files first, oldest to newest, then the current log. Rotated
+	// files are complete, but dockerd may be mid-write on the current one,
+	// so its partial last line is left for FetchNewEvents.
+	paths := b.rotatedPaths(c.ID)
+	for _, path := range paths {
+		if _, err := readEntries(ctx, path, 0, stream, true, callback); err != nil {
+			return err
+		}
+	}
+	path := b.logPath(c.ID)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	offset, err := readEntries(ctx, path, 0, stream, false, callback)
+	if err != nil {
+		return err
+	}
+	b.setPosition(c.ID, stream, position{offset, info})
+	return nil
+}
+
+func (b *Backend) FetchLastEvents(ctx context.Context, group, stream string, limit int) ([]provider.LogEvent, error) {
+	var events []provider.LogEvent
+	err := b.FetchEventsStreaming(ctx, group, stream, func(batch []provider.LogEvent) error {
+		events = append(events, batch...)
+		if len(events) > limit {
+			events = events[len(events)-limit:]
+		}
+		return nil
+	})
+	return events, err
+}
+
+// FetchNewEvents returns entries appended to the container's log since it was
+// last read. since is ignored, as the read offset already marks progress.
+// When the log has been rotated away, the rest of the old file (now
+// <id>-json.log.1) is read first and then the new log from the top; when it
+// has been truncated, reading restarts at the top.
+func (b *Backend) FetchNewEvents(ctx context.Context, group, stream string, since *time.Time) ([]provider.LogEvent, error) {
+	c, err := b.container(group)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStream(stream); err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	last, ok := b.offsets[offsetKey(c.ID, stream)]
+	b.mu.Unlock()
+	if !ok {
+		return nil, nil // not loaded yet
+	}
+	path := b.logPath(c.ID)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	var events []provider.LogEvent
+	collect := func(batch []provider.LogEvent) error {
+		events = append(events, batch...)
+		return nil
+	}
+	start := last.offset
+	if !os.SameFile(info, last.info) {
+		// dockerd renamed the log to .1 and started a new one. If it has
+		// been rotated again since, the rest of the old file is gone.
+		rotated := path + ".1"
+		if old, err := os.Stat(rotated); err == nil && os.SameFile(old, last.info) {
+			if _, err := readEntries(ctx, rotated, last.offset, stream, true, collect); err != nil {
+				return nil, err
+			}
+		}
+		start = 0
+	} else if info.Size() < start {
+		start = 0
+	}
+	offset, err := readEntries(ctx, path, start, stream, false, collect)
+	if err != nil {
+		return nil, err
+	}
+	b.setPosition(c.ID, stream, position{offset, info})
+	return events, nil
+}
+
+// Capabilities: appended entries are followed and streams can be saved;
+// containers are filtered in memory, and there is no query language.
+
+func (b *Backend) SupportsFollow() bool       { return true }
+func (b *Backend) SupportsQuery() bool        { return false }
+func (b *Backend) SupportsServerFilter() bool { return false }
+func (b *Backend) SupportsWrite() bool        { return true }
+
+// Helpers
+
+func offsetKey(id, stream string) string {
+	return id + "/" + stream
+}
+
+func (b *Backend) setPosition(id, stream string, pos position) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.offsets[offsetKey(id, stream)] = pos
+}
+
+func (b *Backend) logPath(id string) string {
+	return filepath.Join(b.root, id, id+"-json.log")
+}
+
+// rotatedPaths returns the container's rotated logs, oldest first. Docker
+// names them <id>-json.log.1 (newest) up to .N (oldest); gzipped rotations
+// (compress=true) are skipped.
+func (b *Backend) rotatedPaths(id string) []string {
+	matches, _ := filepath.Glob(b.logPath(id) + ".*")
+	type rotated struct {
+		path string
+		n    int
+	}
+	var files []rotated
+	for _, path := range matches {
+		n, err := strconv.Atoi(path[strings.LastIndex(path, ".")+1:])
+		if err == nil {
+			files = append(files, rotated{path, n})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].n > files[j].n })
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths
+}
+
+// containers lists the container directories that have a json-file log,
+// sorted by name.
+func (b *Backend) containers() ([]container, error) {
+	entries, err := os.ReadDir(b.root)
+	if err != nil {
+		return nil, err
+	}
+	var result []container
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		id := e.Name()
+		if _, err := os.Stat(b.logPath(id)); err != nil {
+			continue // other logging driver, or never started
+		}
+		c := container{ID: id, Name: shortID(id)}
+		if data, err := os.ReadFile(filepath.Join(b.root, id, "config.v2.json")); err == nil {
+			var config containerConfig
+			if json.Unmarshal(data, &config) == nil {
+				if name := strings.TrimPrefix(config.Name, "/"); name != "" {
+					c.Name = name
+				}
+				c.Image = config.Config.Image
+			}
+		}
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// container resolves a group to a container, by name or by ID prefix.
+func (b *Backend) container(group string) (container, error) {
+	containers, err := b.containers()
+	if err != nil {
+		return container{}, err
+	}
+	for _, c := range containers {
+		if c.Name == group {
+			return c, nil
+		}
+	}
+	var matches []container
+	for _, c := range containers {
+		if len(group) >= 4 && strings.HasPrefix(c.ID, group) {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	if len(matches) > 1 {
+		return container{}, fmt.Errorf("container ID prefix %q is ambiguous", group)
+	}
+	return container{}, fmt.Errorf("container %q not found in %s", group, b.root)
+}
+
+func checkStream(stream string) error {
+	for _, s := range streams {
+		if s == stream {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown stream %q, must be stdout or stderr", stream)
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// entry is one line of a json-file log.
+type entry struct {
+	Log    string    `json:"log"`
+	Stream string    `json:"stream"`
+	Time   time.Time `json:"time"`
+}
+
+// readEntries parses the entries of one stream from the log starting at
+// offset, in batches, and returns the offset after the last line read.
+// Docker splits lines longer than 16KiB into entries without a trailing
+// newline; those are joined back into one event. A partial last line is only
+// read when final is set, as while following it may still be being written.
+func readEntries(ctx context.Context, path string, offset int64, stream string, final bool, callback func([]provider.LogEvent) error) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return offset, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+
+	reader := bufio.NewReaderSize(f, 64*1024)
+	var batch []provider.LogEvent
+	// a split line being joined, and the offset of its first piece
+	var partial strings.Builder
+	var partialTime time.Time
+	partialStart := offset
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF && !final {
+			break // leave a partial last line for the next read
+		}
+		if len(line) > 0 {
+			var e entry
+			if json.Unmarshal([]byte(line), &e) == nil && e.Stream == stream {
+				if partial.Len() == 0 {
+					partialStart, partialTime = offset, e.Time
+				}
+				partial.WriteString(e.Log)
+				if strings.HasSuffix(e.Log, "\n") {
+					t := partialTime
+					batch = append(batch, provider.LogEvent{
+						Message:   strings.TrimRight(partial.String(), "\r\n"),
+						Timestamp: &t,
+					})
+					partial.Reset()
+				}
+			}
+			offset += int64(len(line))
+			if len(batch) >= batchSize {
+				if err := ctx.Err(); err != nil {
+					return offset, err
+				}
+				if err := callback(batch); err != nil {
+					return offset, err
+				}
+				batch = nil
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return offset, err
+		}
+	}
+	if partial.Len() > 0 {
+		if final {
+			// the rest of the split line never arrived (e.g. the container died)
+			t := partialTime
+			batch = append(batch, provider.LogEvent{Message: partial.String(), Timestamp: &t})
+		} else {
+			// reread the split line once the rest has been written
+			offset = partialStart
+		}
+	}
+	if len(batch) > 0 {
+		if err := callback(batch); err != nil {
+			return offset, err
+		}
+	}
+	return offset, nil
+}
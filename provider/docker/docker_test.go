@@ -0,0 +1,234 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/derricw/cwl/provider"
+)
+
+const testID = "3f2a9c1b7d4e5f60718293a4b5c6d7e8f90123456789abcdef0123456789abcd"
+
+// logLine renders a json-file log entry the way dockerd writes it.
+func logLine(t *testing.T, stream, msg string, ts time.Time) string {
+	t.Helper()
+	data, err := json.Marshal(map[string]string{"log": msg, "stream": stream, "time": ts.Format(time.RFC3339Nano)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data) + "\n"
+}
+
+// newContainer creates a container directory named name holding log.
+func newContainer(t *testing.T, root, id, name, log string) string {
+	t.Helper()
+	dir := filepath.Join(root, id)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := `{"Name":"/` + name + `","Config":{"Image":"nginx:1.27"}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.v2.json"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, id+"-json.log")
+	if err := os.WriteFile(path, []byte(log), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func collect(t *testing.T, b *Backend, group, stream string) []provider.LogEvent {
+	t.Helper()
+	var events []provider.LogEvent
+	err := b.FetchEventsStreaming(context.Background(), group, stream, func(batch []provider.LogEvent) error {
+		events = append(events, batch...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func messages(events []provider.LogEvent) []string {
+	msgs := make([]string, len(events))
+	for i, e := range events {
+		msgs[i] = e.Message
+	}
+	return msgs
+}
+
+// TestGroupsAreNamedContainers verifies that containers are listed by the
+// name in config.v2.json, and that directories without a json-file log
+// are skipped.
+func TestGroupsAreNamedContainers(t *testing.T) {
+	root := t.TempDir()
+	newContainer(t, root, testID, "web", "")
+	os.MkdirAll(filepath.Join(root, "journald0000"), 0o755)
+	b, err := New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := b.FetchGroups(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0].Name != "web" || groups[0].Desc != "nginx:1.27 "+testID[:12] {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+	if _, err := b.container(testID[:8]); err != nil {
+		t.Errorf("lookup by ID prefix: %v", err)
+	}
+}
+
+// TestEventsSplitByStream verifies that stdout and stderr are separate
+// streams, timestamps come from the time field, and lines Docker split at
+// 16KiB are joined back together.
+func TestEventsSplitByStream(t *testing.T) {
+	root := t.TempDir()
+	t0 := time.Date(2025, 6, 1, 12, 0, 0, 123456789, time.UTC)
+	log := logLine(t, "stdout", "starting\n", t0) +
+		logLine(t, "stderr", "warning: low disk\n", t0.Add(time.Second)) +
+		logLine(t, "stdout", "a very ", t0.Add(2*time.Second)) +
+		logLine(t, "stdout", "long line\n", t0.Add(3*time.Second))
+	newContainer(t, root, testID, "web", log)
+	b, _ := New(root)
+
+	stdout := collect(t, b, "web", "stdout")
+	if got := strings.Join(messages(stdout), "|"); got != "starting|a very long line" {
+		t.Errorf("stdout = %q", got)
+	}
+	if !stdout[0].Timestamp.Equal(t0) || !stdout[1].Timestamp.Equal(t0.Add(2*time.Second)) {
+		t.Errorf("unexpected timestamps %v, %v", stdout[0].Timestamp, stdout[1].Timestamp)
+	}
+	if got := messages(collect(t, b, "web", "stderr")); len(got) != 1 || got[0] != "warning: low disk" {
+		t.Errorf("stderr = %q", got)
+	}
+	if err := b.FetchEventsStreaming(context.Background(), "web", "stdin", func([]provider.LogEvent) error { return nil }); err == nil {
+		t.Error("expected an error for an unknown stream")
+	}
+}
+
+// TestRotatedLogsReadOldestFirst verifies that rotated logs are read before
+// the current one, from the highest number down.
+func TestRotatedLogsReadOldestFirst(t *testing.T) {
+	root := t.TempDir()
+	t0 := time.Now()
+	path := newContainer(t, root, testID, "web", logLine(t, "stdout", "3\n", t0))
+	os.WriteFile(path+".1", []byte(logLine(t, "stdout", "2\n", t0)), 0o644)
+	os.WriteFile(path+".2", []byte(logLine(t, "stdout", "1\n", t0)), 0o644)
+	b, _ := New(root)
+
+	if got := strings.Join(messages(collect(t, b, "web", "stdout")), ","); got != "1,2,3" {
+		t.Errorf("got %q, want 1,2,3", got)
+	}
+}
+
+// TestFollowAppendedEntries verifies that FetchNewEvents returns only
+// entries appended since the last read, holds back a split line until it
+// is complete, and starts over when the log is rotated.
+func TestFollowAppendedEntries(t *testing.T) {
+	root := t.TempDir()
+	t0 := time.Now()
+	path := newContainer(t, root, testID, "web", logLine(t, "stdout", "old\n", t0))
+	b, _ := New(root)
+	collect(t, b, "web", "stdout")
+
+	appendLog := func(s string) {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.WriteString(s)
+		f.Close()
+	}
+	poll := func() []string {
+		events, err := b.FetchNewEvents(context.Background(), "web", "stdout", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return messages(events)
+	}
+
+	appendLog(logLine(t, "stderr", "other stream\n", t0) + logLine(t, "stdout", "new\n", t0) + logLine(t, "stdout", "half ", t0))
+	if got := poll(); len(got) != 1 || got[0] != "new" {
+		t.Fatalf("first poll = %q", got)
+	}
+	appendLog(logLine(t, "stdout", "done\n", t0))
+	if got := poll(); len(got) != 1 || got[0] != "half done" {
+		t.Fatalf("second poll = %q", got)
+	}
+
+	os.WriteFile(path, []byte(logLine(t, "stdout", "rotated\n", t0)), 0o644)
+	if got := poll(); len(got) != 1 || got[0] != "rotated" {
+		t.Fatalf("poll after rotation = %q", got)
+	}
+}
+
+// TestFirstLoadLeavesHalfWrittenEntry verifies that an entry dockerd is
+// still writing when the log is first loaded is delivered by the next poll
+// instead of being lost.
+func TestFirstLoadLeavesHalfWrittenEntry(t *testing.T) {
+	root := t.TempDir()
+	t0 := time.Now()
+	last := logLine(t, "stdout", "second\n", t0)
+	path := newContainer(t, root, testID, "web", logLine(t, "stdout", "first\n", t0)+last[:10])
+	b, _ := New(root)
+	if got := messages(collect(t, b, "web", "stdout")); len(got) != 1 || got[0] != "first" {
+		t.Fatalf("first load = %q", got)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(last[10:])
+	f.Close()
+	events, err := b.FetchNewEvents(context.Background(), "web", "stdout", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(events); len(got) != 1 || got[0] != "second" {
+		t.Fatalf("poll = %q", got)
+	}
+}
+
+// TestFollowAcrossRotation verifies that when the log is renamed to .1 and a
+// new one started, entries written to the old file before the rename are
+// still returned, followed by the new file's, even when the new file is
+// already longer than the old offset.
+func TestFollowAcrossRotation(t *testing.T) {
+	root := t.TempDir()
+	t0 := time.Now()
+	path := newContainer(t, root, testID, "web", logLine(t, "stdout", "old\n", t0))
+	b, _ := New(root)
+	collect(t, b, "web", "stdout")
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(logLine(t, "stdout", "last before rotation\n", t0))
+	f.Close()
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	fresh := strings.Repeat(logLine(t, "stdout", "new\n", t0), 5)
+	if err := os.WriteFile(path, []byte(fresh), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := b.FetchNewEvents(context.Background(), "web", "stdout", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(messages(events), ","); got != "last before rotation,new,new,new,new,new" {
+		t.Errorf("poll after rotation = %q", got)
+	}
+}
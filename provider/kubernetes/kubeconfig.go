@@ -0,0 +1,317 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// kubeconfig is the part of a kubeconfig file cwl uses.
+type kubeconfig struct {
+	CurrentContext string `json:"current-context"`
+	Contexts       []struct {
+		Name    string `json:"name"`
+		Context struct {
+			Cluster   string `json:"cluster"`
+			User      string `json:"user"`
+			Namespace string `json:"namespace"`
+		} `json:"context"`
+	} `json:"contexts"`
+	Clusters []struct {
+		Name    string `json:"name"`
+		Cluster struct {
+			Server                   string `json:"server"`
+			CertificateAuthority     string `json:"certificate-authority"`
+			CertificateAuthorityData string `json:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+		} `json:"cluster"`
+	} `json:"clusters"`
+	Users []struct {
+		Name string   `json:"name"`
+		User authInfo `json:"user"`
+	} `json:"users"`
+}
+
+type authInfo struct {
+	Token                 string      `json:"token"`
+	TokenFile             string      `json:"tokenFile"`
+	ClientCertificate     string      `json:"client-certificate"`
+	ClientCertificateData string      `json:"client-certificate-data"`
+	ClientKey             string      `json:"client-key"`
+	ClientKeyData         string      `json:"client-key-data"`
+	Username              string      `json:"username"`
+	Password              string      `json:"password"`
+	Exec                  *execConfig `json:"exec"`
+}
+
+// execConfig runs a credential plugin, e.g. `aws eks get-token` on EKS.
+type execConfig struct {
+	APIVersion string   `json:"apiVersion"`
+	Command    string   `json:"command"`
+	Args       []string `json:"args"`
+	Env        []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"env"`
+}
+
+// defaultKubeconfig returns $KUBECONFIG, or ~/.kube/config.
+func defaultKubeconfig() string {
+	if path := os.Getenv("KUBECONFIG"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".kube", "config")
+}
+
+// loadKubeconfig reads a kubeconfig, or merges a $KUBECONFIG list of them
+// the way kubectl does: the first file to set the current context or to
+// name a context, cluster or user wins, and missing files in a list are
+// skipped. Relative file paths are made absolute against the directory of
+// the kubeconfig they appear in.
+func loadKubeconfig(path string) (*kubeconfig, error) {
+	paths := filepath.SplitList(path)
+	merged := &kubeconfig{}
+	seen := map[string]bool{}
+	loaded := 0
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		data, err := os.ReadFile(p)
+		if errors.Is(err, fs.ErrNotExist) && len(paths) > 1 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var config kubeconfig
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("invalid kubeconfig %s: %w", p, err)
+		}
+		config.absolutize(filepath.Dir(p))
+		merged.merge(&config, seen)
+		loaded++
+	}
+	if loaded == 0 {
+		return nil, fmt.Errorf("no kubeconfig found in %s", path)
+	}
+	return merged, nil
+}
+
+// absolutize resolves the relative file paths in k against dir.
+func (k *kubeconfig) absolutize(dir string) {
+	abs := func(path *string) {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(dir, *path)
+		}
+	}
+	for i := range k.Clusters {
+		abs(&k.Clusters[i].Cluster.CertificateAuthority)
+	}
+	for i := range k.Users {
+		user := &k.Users[i].User
+		abs(&user.TokenFile)
+		abs(&user.ClientCertificate)
+		abs(&user.ClientKey)
+	}
+}
+
+// merge adds the entries of other that k doesn't have yet. seen records
+// the names already taken, by kind.
+func (k *kubeconfig) merge(other *kubeconfig, seen map[string]bool) {
+	if k.CurrentContext == "" {
+		k.CurrentContext = other.CurrentContext
+	}
+	first := func(kind, name string) bool {
+		key := kind + "/" + name
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+		return true
+	}
+	for _, c := range other.Contexts {
+		if first("context", c.Name) {
+			k.Contexts = append(k.Contexts, c)
+		}
+	}
+	for _, c := range other.Clusters {
+		if first("cluster", c.Name) {
+			k.Clusters = append(k.Clusters, c)
+		}
+	}
+	for _, u := range other.Users {
+		if first("user", u.Name) {
+			k.Users = append(k.Users, u)
+		}
+	}
+}
+
+// resolve builds the connection for a context, or the current context when
+// name is empty.
+func (k *kubeconfig) resolve(name string) (Config, error) {
+	if name == "" {
+		name = k.CurrentContext
+	}
+	if name == "" {
+		return Config{}, fmt.Errorf("kubeconfig has no current context: pass one as kubernetes://<context>")
+	}
+	var clusterName, userName string
+	found := false
+	for _, c := range k.Contexts {
+		if c.Name == name {
+			clusterName, userName, found = c.Context.Cluster, c.Context.User, true
+		}
+	}
+	if !found {
+		return Config{}, fmt.Errorf("context %q not found in kubeconfig", name)
+	}
+
+	cfg := Config{}
+	tlsConfig := &tls.Config{}
+	found = false
+	for _, c := range k.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		cfg.Server = c.Cluster.Server
+		tlsConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+		ca, err := fileOrData(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority)
+		if err != nil {
+			return Config{}, fmt.Errorf("cluster %s certificate authority: %w", clusterName, err)
+		}
+		if ca != nil {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return Config{}, fmt.Errorf("cluster %s: no certificates in certificate authority", clusterName)
+			}
+			tlsConfig.RootCAs = pool
+		}
+	}
+	if !found {
+		return Config{}, fmt.Errorf("cluster %q not found in kubeconfig", clusterName)
+	}
+
+	for _, u := range k.Users {
+		if u.Name != userName {
+			continue
+		}
+		auth := u.User
+		cert, err := fileOrData(auth.ClientCertificateData, auth.ClientCertificate)
+		if err != nil {
+			return Config{}, fmt.Errorf("user %s client certificate: %w", userName, err)
+		}
+		key, err := fileOrData(auth.ClientKeyData, auth.ClientKey)
+		if err != nil {
+			return Config{}, fmt.Errorf("user %s client key: %w", userName, err)
+		}
+		if cert != nil && key != nil {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return Config{}, fmt.Errorf("user %s: %w", userName, err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+		cfg.Username, cfg.Password = auth.Username, auth.Password
+		switch {
+		case auth.Token != "":
+			token := auth.Token
+			cfg.Token = func(context.Context) (string, error) { return token, nil }
+		case auth.TokenFile != "":
+			path := auth.TokenFile
+			cfg.Token = func(context.Context) (string, error) {
+				data, err := os.ReadFile(path)
+				return strings.TrimSpace(string(data)), err
+			}
+		case auth.Exec != nil:
+			cfg.Token = (&execToken{config: *auth.Exec}).get
+		}
+	}
+	cfg.Client = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment}}
+	return cfg, nil
+}
+
+// fileOrData returns base64 data from the kubeconfig, or the contents of a
+// file it names, or nil when neither is set.
+func fileOrData(data, path string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if path == "" {
+		return nil, nil
+	}
+	return os.ReadFile(path)
+}
+
+// execToken runs a credential plugin and caches its token until it expires.
+type execToken struct {
+	config  execConfig
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// execCredential is what credential plugins print.
+type execCredential struct {
+	Status struct {
+		Token               string     `json:"token"`
+		ExpirationTimestamp *time.Time `json:"expirationTimestamp"`
+	} `json:"status"`
+}
+
+func (e *execToken) get(ctx context.Context) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.token != "" && (e.expires.IsZero() || time.Now().Before(e.expires.Add(-time.Minute))) {
+		return e.token, nil
+	}
+	cmd := exec.CommandContext(ctx, e.config.Command, e.config.Args...)
+	cmd.Env = os.Environ()
+	for _, env := range e.config.Env {
+		cmd.Env = append(cmd.Env, env.Name+"="+env.Value)
+	}
+	apiVersion := e.config.APIVersion
+	if apiVersion == "" {
+		apiVersion = "client.authentication.k8s.io/v1beta1"
+	}
+	cmd.Env = append(cmd.Env, fmt.Sprintf(`KUBERNETES_EXEC_INFO={"apiVersion":%q,"kind":"ExecCredential","spec":{"interactive":false}}`, apiVersion))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("credential plugin %s: %w: %s", e.config.Command, err, strings.TrimSpace(stderr.String()))
+	}
+	var cred execCredential
+	if err := json.Unmarshal(out, &cred); err != nil {
+		return "", fmt.Errorf("credential plugin %s: %w", e.config.Command, err)
+	}
+	if cred.Status.Token == "" {
+		return "", fmt.Errorf("credential plugin %s returned no token", e.config.Command)
+	}
+	e.token = cred.Status.Token
+	e.expires = time.Time{}
+	if cred.Status.ExpirationTimestamp != nil {
+		e.expires = *cred.Status.ExpirationTimestamp
+	}
+	return e.token, nil
+}
@@ -0,0 +1,340 @@
+// Package kubernetes implements provider.Backend for pod logs, read through
+// the Kubernetes API with the credentials in a kubeconfig.
+// Maps: namespaces → groups, pod/container pairs → streams, log lines → events.
+//
+// Only the API server's REST endpoints are used, so no client libraries are
+// needed. Following polls the log endpoint with sinceTime.
+package kubernetes
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/derricw/cwl/provider"
+)
+
+// batchSize is the number of events passed to each streaming callback.
+const batchSize = 1000
+
+// Config is a resolved connection to one cluster.
+type Config struct {
+	Server string
+	// Client carries the cluster's TLS settings and client certificate.
+	Client *http.Client
+	// Token returns the bearer token, or is nil when none is used.
+	Token              func(ctx context.Context) (string, error)
+	Username, Password string
+}
+
+type Backend struct {
+	config Config
+}
+
+func init() {
+	provider.Register("kubernetes", open)
+	provider.Register("k8s", open)
+}
+
+// open handles kubernetes:// (the current context) and
+// kubernetes://<context>. The context is taken verbatim, since EKS context
+// names are ARNs.
+func open(uri string, opts provider.OpenOptions) (provider.Backend, error) {
+	_, contextName, _ := strings.Cut(uri, "://")
+	return NewFromKubeconfig("", contextName)
+}
+
+// New creates a backend for a resolved connection.
+func New(config Config) *Backend {
+	config.Server = strings.TrimRight(config.Server, "/")
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	return &Backend{config: config}
+}
+
+// NewFromKubeconfig creates a backend for a kubeconfig context. An empty
+// path means $KUBECONFIG or ~/.kube/config, and an empty context name the
+// current context.
+func NewFromKubeconfig(path, contextName string) (*Backend, error) {
+	if path == "" {
+		path = defaultKubeconfig()
+	}
+	kc, err := loadKubeconfig(path)
+	if err != nil {
+		return nil, err
+	}
+	config, err := kc.resolve(contextName)
+	if err != nil {
+		return nil, err
+	}
+	return New(config), nil
+}
+
+// API response types
+
+type namespaceList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Status struct {
+			Phase string `json:"phase"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+type podList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Spec struct {
+			InitContainers []struct {
+				Name string `json:"name"`
+			} `json:"initContainers"`
+			Containers []struct {
+				Name string `json:"name"`
+			} `json:"containers"`
+		} `json:"spec"`
+		Status struct {
+			InitContainerStatuses []containerStatus `json:"initContainerStatuses"`
+			ContainerStatuses     []containerStatus `json:"containerStatuses"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+type containerStatus struct {
+	Name  string `json:"name"`
+	State struct {
+		Running *struct {
+			StartedAt time.Time `json:"startedAt"`
+		} `json:"running"`
+		Terminated *struct {
+			FinishedAt time.Time `json:"finishedAt"`
+		} `json:"terminated"`
+	} `json:"state"`
+}
+
+// lastActive is when the container was last known to be logging: now while
+// it runs, or when it finished.
+func (s containerStatus) lastActive(now time.Time) *time.Time {
+	switch {
+	case s.State.Running != nil:
+		return &now
+	case s.State.Terminated != nil:
+		t := s.State.Terminated.FinishedAt
+		return &t
+	}
+	return nil
+}
+
+// Backend interface implementation
+
+func (b *Backend) FetchGroups(ctx context.Context, pattern string) ([]provider.LogGroup, error) {
+	var list namespaceList
+	if err := b.getJSON(ctx, "/api/v1/namespaces", nil, &list); err != nil {
+		return nil, err
+	}
+	var groups []provider.LogGroup
+	for _, ns := range list.Items {
+		name := ns.Metadata.Name
+		if pattern != "" && !strings.Contains(strings.ToLower(name), strings.ToLower(pattern)) {
+			continue
+		}
+		groups = append(groups, provider.LogGroup{Name: name, Desc: ns.Status.Phase})
+	}
+	return groups, nil
+}
+
+// FetchStreamsStreaming lists a stream per container, init containers
+// included, named pod/container. Running containers sort first, then by
+// when they finished.
+func (b *Backend) FetchStreamsStreaming(ctx context.Context, group string, callback func([]provider.LogStream) error) error {
+	var list podList
+	if err := b.getJSON(ctx, "/api/v1/namespaces/"+url.PathEscape(group)+"/pods", nil, &list); err != nil {
+		return err
+	}
+	now := time.Now()
+	var streams []provider.LogStream
+	for _, pod := range list.Items {
+		statuses := map[string]containerStatus{}
+		for _, s := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			statuses[s.Name] = s
+		}
+		var names []string
+		for _, c := range pod.Spec.InitContainers {
+			names = append(names, c.Name)
+		}
+		for _, c := range pod.Spec.Containers {
+			names = append(names, c.Name)
+		}
+		for _, name := range names {
+			stream := provider.LogStream{Name: pod.Metadata.Name + "/" + name}
+			if status, ok := statuses[name]; ok {
+				stream.LastEventTime = status.lastActive(now)
+			}
+			streams = append(streams, stream)
+		}
+	}
+	if len(streams) == 0 {
+		return nil
+	}
+	sort.SliceStable(streams, func(i, j int) bool {
+		a, b := streams[i].LastEventTime, streams[j].LastEventTime
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.After(*b)
+	})
+	return callback(streams)
+}
+
+func (b *Backend) FetchEventsStreaming(ctx context.Context, group, stream string, callback func([]provider.LogEvent) error) error {
+	return b.readLog(ctx, group, stream, url.Values{}, nil, callback)
+}
+
+func (b *Backend) FetchLastEvents(ctx context.Context, group, stream string, limit int) ([]provider.LogEvent, error) {
+	var events []provider.LogEvent
+	err := b.readLog(ctx, group, stream, url.Values{"tailLines": {strconv.Itoa(limit)}}, nil, func(batch []provider.LogEvent) error {
+		events = append(events, batch...)
+		return nil
+	})
+	return events, err
+}
+
+// FetchNewEvents asks for lines since the given time. sinceTime only has
+// second precision, so lines at or before since are dropped here.
+func (b *Backend) FetchNewEvents(ctx context.Context, group, stream string, since *time.Time) ([]provider.LogEvent, error) {
+	if since == nil {
+		return nil, nil
+	}
+	params := url.Values{"sinceTime": {since.UTC().Truncate(time.Second).Format(time.RFC3339)}}
+	var events []provider.LogEvent
+	err := b.readLog(ctx, group, stream, params, since, func(batch []provider.LogEvent) error {
+		events = append(events, batch...)
+		return nil
+	})
+	return events, err
+}
+
+// Capabilities: pods keep logging and their logs can be saved; namespaces
+// are filtered in memory, and there is no query language.
+
+func (b *Backend) SupportsFollow() bool       { return true }
+func (b *Backend) SupportsQuery() bool        { return false }
+func (b *Backend) SupportsServerFilter() bool { return false }
+func (b *Backend) SupportsWrite() bool        { return true }
+
+// Helpers
+
+// readLog streams a container's log with timestamps, in batches. Lines at
+// or before after (when set) are skipped.
+func (b *Backend) readLog(ctx context.Context, namespace, stream string, params url.Values, after *time.Time, callback func([]provider.LogEvent) error) error {
+	pod, container, ok := strings.Cut(stream, "/")
+	if !ok {
+		return fmt.Errorf("invalid stream %q: expected pod/container", stream)
+	}
+	params.Set("container", container)
+	params.Set("timestamps", "true")
+	body, err := b.get(ctx, "/api/v1/namespaces/"+url.PathEscape(namespace)+"/pods/"+url.PathEscape(pod)+"/log", params)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	reader := bufio.NewReaderSize(body, 64*1024)
+	var batch []provider.LogEvent
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			e := parseLine(strings.TrimRight(line, "\r\n"))
+			if after == nil || e.Timestamp == nil || e.Timestamp.After(*after) {
+				batch = append(batch, e)
+			}
+			if len(batch) >= batchSize {
+				if err := callback(batch); err != nil {
+					return err
+				}
+				batch = nil
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(batch) > 0 {
+		return callback(batch)
+	}
+	return nil
+}
+
+// parseLine splits the RFC3339 timestamp the API prepends with
+// timestamps=true from the message.
+func parseLine(line string) provider.LogEvent {
+	ts, msg, ok := strings.Cut(line, " ")
+	if ok {
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			return provider.LogEvent{Message: msg, Timestamp: &t}
+		}
+	}
+	return provider.LogEvent{Message: line}
+}
+
+func (b *Backend) getJSON(ctx context.Context, path string, params url.Values, result interface{}) error {
+	body, err := b.get(ctx, path, params)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return json.NewDecoder(body).Decode(result)
+}
+
+// get performs an authenticated GET and returns the body of a successful
+// response. API errors are returned as their Status message.
+func (b *Backend) get(ctx context.Context, path string, params url.Values) (io.ReadCloser, error) {
+	u := b.config.Server + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if b.config.Token != nil {
+		token, err := b.config.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if b.config.Username != "" {
+		req.SetBasicAuth(b.config.Username, b.config.Password)
+	}
+	resp, err := b.config.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var status struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(data, &status) == nil && status.Message != "" {
+			return nil, fmt.Errorf("kubernetes API error: %s: %s", resp.Status, status.Message)
+		}
+		return nil, fmt.Errorf("kubernetes API error: %s", resp.Status)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,257 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/derricw/cwl/provider"
+)
+
+const testToken = "s3cret"
+
+var t0 = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeAPIServer serves namespaces, pods and container logs, and requires
+// the test bearer token.
+func fakeAPIServer(t *testing.T, logs map[string][]string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/namespaces", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"items":[{"metadata":{"name":"default"},"status":{"phase":"Active"}},{"metadata":{"name":"payments"},"status":{"phase":"Active"}}]}`)
+	})
+	mux.HandleFunc("/api/v1/namespaces/payments/pods", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"items":[
+			{"metadata":{"name":"api-1"},
+			 "spec":{"initContainers":[{"name":"migrate"}],"containers":[{"name":"api"},{"name":"proxy"}]},
+			 "status":{"initContainerStatuses":[{"name":"migrate","state":{"terminated":{"finishedAt":%q}}}],
+			           "containerStatuses":[{"name":"api","state":{"running":{"startedAt":%q}}},{"name":"proxy","state":{"waiting":{}}}]}}]}`,
+			t0.Format(time.RFC3339), t0.Format(time.RFC3339))
+	})
+	mux.HandleFunc("/api/v1/namespaces/payments/pods/api-1/log", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("timestamps") != "true" {
+			t.Errorf("expected timestamps=true, got %v", q)
+		}
+		lines := logs[q.Get("container")]
+		if since := q.Get("sinceTime"); since != "" {
+			st, _ := time.Parse(time.RFC3339, since)
+			var kept []string
+			for _, l := range lines {
+				ts, _ := time.Parse(time.RFC3339Nano, strings.Fields(l)[0])
+				if !ts.Before(st) {
+					kept = append(kept, l)
+				}
+			}
+			lines = kept
+		}
+		if n := q.Get("tailLines"); n != "" {
+			var tail int
+			fmt.Sscan(n, &tail)
+			if len(lines) > tail {
+				lines = lines[len(lines)-tail:]
+			}
+		}
+		for _, l := range lines {
+			fmt.Fprintln(w, l)
+		}
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"kind":"Status","message":"Unauthorized"}`)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func logLine(at time.Duration, msg string) string {
+	return t0.Add(at).Format(time.RFC3339Nano) + " " + msg
+}
+
+// writeKubeconfig writes a JSON kubeconfig for server with the given user.
+func writeKubeconfig(t *testing.T, server string, user map[string]interface{}) string {
+	t.Helper()
+	config := map[string]interface{}{
+		"current-context": "test",
+		"contexts":        []interface{}{map[string]interface{}{"name": "test", "context": map[string]string{"cluster": "c", "user": "u"}}},
+		"clusters":        []interface{}{map[string]interface{}{"name": "c", "cluster": map[string]string{"server": server}}},
+		"users":           []interface{}{map[string]interface{}{"name": "u", "user": user}},
+	}
+	data, _ := json.Marshal(config)
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func messages(events []provider.LogEvent) string {
+	msgs := make([]string, len(events))
+	for i, e := range events {
+		msgs[i] = e.Message
+	}
+	return strings.Join(msgs, ",")
+}
+
+// TestBrowseNamespacesPodsAndLogs verifies that namespaces are groups,
+// containers are pod/container streams with running ones first, and logs
+// carry the timestamps the API prepends.
+func TestBrowseNamespacesPodsAndLogs(t *testing.T) {
+	srv := fakeAPIServer(t, map[string][]string{
+		"api": {logLine(0, "listening on :8080"), logLine(time.Second, "GET /health 200")},
+	})
+	b, err := NewFromKubeconfig(writeKubeconfig(t, srv.URL, map[string]interface{}{"token": testToken}), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	groups, err := b.FetchGroups(ctx, "pay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0].Name != "payments" {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+
+	var names []string
+	err = b.FetchStreamsStreaming(ctx, "payments", func(streams []provider.LogStream) error {
+		for _, s := range streams {
+			names = append(names, s.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(names, ","); got != "api-1/api,api-1/migrate,api-1/proxy" {
+		t.Errorf("streams = %s", got)
+	}
+
+	var events []provider.LogEvent
+	err = b.FetchEventsStreaming(ctx, "payments", "api-1/api", func(batch []provider.LogEvent) error {
+		events = append(events, batch...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(events); got != "listening on :8080,GET /health 200" {
+		t.Errorf("events = %s", got)
+	}
+	if !events[1].Timestamp.Equal(t0.Add(time.Second)) {
+		t.Errorf("timestamp = %v", events[1].Timestamp)
+	}
+}
+
+// TestNewEventsSinceTime verifies that polling passes sinceTime and drops
+// lines from the same second that were already seen.
+func TestNewEventsSinceTime(t *testing.T) {
+	srv := fakeAPIServer(t, map[string][]string{
+		"api": {logLine(0, "a"), logLine(100*time.Millisecond, "b"), logLine(200*time.Millisecond, "c"), logLine(2*time.Second, "d")},
+	})
+	b := New(Config{Server: srv.URL, Token: func(context.Context) (string, error) { return testToken, nil }})
+
+	last, err := b.FetchLastEvents(context.Background(), "payments", "api-1/api", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(last); got != "b,c,d" {
+		t.Errorf("last events = %s", got)
+	}
+	since := t0.Add(100 * time.Millisecond)
+	newEvents, err := b.FetchNewEvents(context.Background(), "payments", "api-1/api", &since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(newEvents); got != "c,d" {
+		t.Errorf("new events = %s, want c,d", got)
+	}
+}
+
+// TestExecCredentialPlugin verifies that tokens from exec plugins (as used
+// by EKS) are sent, and that API errors surface the server's message.
+func TestExecCredentialPlugin(t *testing.T) {
+	srv := fakeAPIServer(t, nil)
+	plugin := map[string]interface{}{"exec": map[string]interface{}{
+		"apiVersion": "client.authentication.k8s.io/v1beta1",
+		"command":    "sh",
+		"args":       []string{"-c", `echo "{\"status\":{\"token\":\"$TOKEN\"}}"`},
+		"env":        []map[string]string{{"name": "TOKEN", "value": testToken}},
+	}}
+	b, err := NewFromKubeconfig(writeKubeconfig(t, srv.URL, plugin), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.FetchGroups(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ = NewFromKubeconfig(writeKubeconfig(t, srv.URL, map[string]interface{}{"token": "wrong"}), "")
+	if _, err := b.FetchGroups(context.Background(), ""); err == nil || !strings.Contains(err.Error(), "Unauthorized") {
+		t.Errorf("expected an Unauthorized error, got %v", err)
+	}
+	if _, err := NewFromKubeconfig(writeKubeconfig(t, srv.URL, nil), "missing"); err == nil {
+		t.Error("expected an error for a missing context")
+	}
+}
+
+// TestYAMLKubeconfigList verifies that YAML kubeconfigs are read without
+// kubectl, and that a $KUBECONFIG list is merged with the first file
+// winning, skipping missing files and resolving each file's relative paths
+// against its own directory.
+func TestYAMLKubeconfigList(t *testing.T) {
+	srv := fakeAPIServer(t, nil)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte(testToken+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	first := filepath.Join(dir, "first.yaml")
+	os.WriteFile(first, []byte(`apiVersion: v1
+kind: Config
+current-context: test
+contexts:
+- name: test
+  context:
+    cluster: c
+    user: u
+users:
+- name: u
+  user:
+    tokenFile: token
+`), 0o600)
+	second := filepath.Join(t.TempDir(), "second.yaml")
+	os.WriteFile(second, []byte(`current-context: other
+clusters:
+- name: c
+  cluster:
+    server: "`+srv.URL+`"
+users:
+- name: u
+  user:
+    token: wrong
+`), 0o600)
+
+	path := strings.Join([]string{first, filepath.Join(dir, "missing"), second}, string(os.PathListSeparator))
+	b, err := NewFromKubeconfig(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.FetchGroups(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewFromKubeconfig(filepath.Join(dir, "missing"), ""); err == nil {
+		t.Error("expected an error for a missing kubeconfig")
+	}
+}
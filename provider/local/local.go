@@ -0,0 +1,406 @@
+// Package local implements provider.Backend for log files on disk, so the
+// TUI works without network access.
+// Maps: directories → groups, .log/.jsonl files and numbered rotations such
+// as app.log.1 or syslog.2.gz (optionally gzipped) → streams, lines → events.
+//
+// The root directory itself is a group (named after the directory) when it
+// holds log files directly, as ~/Downloads/cwl/logs does. Each top-level
+// subdirectory is a group too, with every log file below it as a stream, so
+// a `cwl export` dump whose stream names contain slashes reads back with the
+// same names.
+package local
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/derricw/cwl/provider"
+)
+
+// batchSize is the number of events passed to each streaming callback.
+const batchSize = 1000
+
+// streamExtensions are the file extensions treated as streams, and stripped
+// from their names. Numbered rotations are streams too; anything else
+// (manifests, partial downloads) is ignored.
+var streamExtensions = []string{".log", ".jsonl", ".log.gz", ".jsonl.gz"}
+
+type Backend struct {
+	root string
+	// offsets records how far each plain file has been read, so
+	// FetchNewEvents returns only lines appended since.
+	mu      sync.Mutex
+	offsets map[string]int64
+}
+
+func init() {
+	provider.Register("file", open)
+}
+
+// open handles file:///abs/path and file://relative/path. The path is taken
+// verbatim rather than URL-parsed, so relative paths work too.
+func open(uri string, opts provider.OpenOptions) (provider.Backend, error) {
+	_, path, _ := strings.Cut(uri, "://")
+	if path == "" {
+		return nil, fmt.Errorf("invalid source %q: missing directory", uri)
+	}
+	return New(path)
+}
+
+// New creates a backend rooted at dir.
+func New(dir string) (*Backend, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return &Backend{root: filepath.Clean(dir), offsets: map[string]int64{}}, nil
+}
+
+// Backend interface implementation
+
+func (b *Backend) FetchGroups(ctx context.Context, pattern string) ([]provider.LogGroup, error) {
+	entries, err := os.ReadDir(b.root)
+	if err != nil {
+		return nil, err
+	}
+	var groups []provider.LogGroup
+	hasFiles := false
+	for _, e := range entries {
+		if e.IsDir() {
+			groups = append(groups, provider.LogGroup{Name: e.Name(), Desc: filepath.Join(b.root, e.Name())})
+		} else if streamName(e.Name()) != "" {
+			hasFiles = true
+		}
+	}
+	if hasFiles {
+		root := provider.LogGroup{Name: b.rootName(), Desc: b.root}
+		groups = append([]provider.LogGroup{root}, groups...)
+	}
+	if pattern == "" {
+		return groups, nil
+	}
+	var matched []provider.LogGroup
+	for _, g := range groups {
+		if strings.Contains(strings.ToLower(g.Name), strings.ToLower(pattern)) {
+			matched = append(matched, g)
+		}
+	}
+	return matched, nil
+}
+
+func (b *Backend) FetchStreamsStreaming(ctx context.Context, group string, callback func([]provider.LogStream) error) error {
+	dir, recursive, err := b.groupDir(group)
+	if err != nil {
+		return err
+	}
+	var streams []provider.LogStream
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := streamName(filepath.ToSlash(rel))
+		if name == "" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		modTime := info.ModTime()
+		streams = append(streams, provider.LogStream{Name: name, LastEventTime: &modTime})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// newest first, like CloudWatch
+	sort.SliceStable(streams, func(i, j int) bool {
+		return streams[i].LastEventTime.After(*streams[j].LastEventTime)
+	})
+	if len(streams) == 0 {
+		return nil
+	}
+	return callback(streams)
+}
+
+func (b *Backend) FetchEventsStreaming(ctx context.Context, group, stream string, callback func([]provider.LogEvent) error) error {
+	path, err := b.streamPath(group, stream)
+	if err != nil {
+		return err
+	}
+	offset, err := readEvents(ctx, path, 0, true, callback)
+	if err != nil {
+		return err
+	}
+	b.setOffset(path, offset)
+	return nil
+}
+
+func (b *Backend) FetchLastEvents(ctx context.Context, group, stream string, limit int) ([]provider.LogEvent, error) {
+	path, err := b.streamPath(group, stream)
+	if err != nil {
+		return nil, err
+	}
+	var events []provider.LogEvent
+	_, err = readEvents(ctx, path, 0, true, func(batch []provider.LogEvent) error {
+		events = append(events, batch...)
+		if len(events) > limit {
+			events = events[len(events)-limit:]
+		}
+		return nil
+	})
+	return events, err
+}
+
+// FetchNewEvents returns lines appended to the file since it was last read.
+// since is ignored: files often have no timestamps, so the read offset is
+// what marks progress. Gzipped files are treated as complete.
+func (b *Backend) FetchNewEvents(ctx context.Context, group, stream string, since *time.Time) ([]provider.LogEvent, error) {
+	path, err := b.streamPath(group, stream)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(path, ".gz") {
+		return nil, nil
+	}
+	b.mu.Lock()
+	start, ok := b.offsets[path]
+	b.mu.Unlock()
+	if !ok {
+		return nil, nil // not loaded yet
+	}
+	var events []provider.LogEvent
+	offset, err := readEvents(ctx, path, start, false, func(batch []provider.LogEvent) error {
+		events = append(events, batch...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.setOffset(path, offset)
+	return events, nil
+}
+
+// Capabilities: appended lines are followed and files can be saved;
+// directories are filtered in memory, and there is no query language.
+
+func (b *Backend) SupportsFollow() bool       { return true }
+func (b *Backend) SupportsQuery() bool        { return false }
+func (b *Backend) SupportsServerFilter() bool { return false }
+func (b *Backend) SupportsWrite() bool        { return true }
+
+// Helpers
+
+func (b *Backend) rootName() string {
+	return filepath.Base(b.root)
+}
+
+func (b *Backend) setOffset(path string, offset int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.offsets[path] = offset
+}
+
+// groupDir resolves a group name to its directory. The root group only
+// covers files directly in the root, since its subdirectories are groups of
+// their own.
+func (b *Backend) groupDir(group string) (dir string, recursive bool, err error) {
+	if group == "" || strings.Contains(group, "..") || strings.ContainsAny(group, `/\`) {
+		return "", false, fmt.Errorf("invalid group %q", group)
+	}
+	dir = filepath.Join(b.root, group)
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir, true, nil
+	}
+	if group == b.rootName() {
+		return b.root, false, nil
+	}
+	return "", false, fmt.Errorf("group %q not found in %s", group, b.root)
+}
+
+// streamPath finds the file for a stream by trying each extension.
+func (b *Backend) streamPath(group, stream string) (string, error) {
+	dir, _, err := b.groupDir(group)
+	if err != nil {
+		return "", err
+	}
+	for _, segment := range strings.Split(stream, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("invalid stream %q", stream)
+		}
+	}
+	exts := streamExtensions
+	if rotated(stream) {
+		// rotations keep their full name, so at most ".gz" is added
+		exts = append([]string{"", ".gz"}, exts...)
+	}
+	for _, ext := range exts {
+		path := filepath.Join(dir, filepath.FromSlash(stream)+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("stream %q not found in group %q", stream, group)
+}
+
+// streamName strips a stream extension from a file name, or returns "" if
+// the file isn't a stream. A numbered rotation, gzipped or not, keeps its
+// name (minus ".gz") so it doesn't clash with the live file: app.log.1.gz
+// is the stream app.log.1.
+func streamName(file string) string {
+	// longest extensions first, so ".log.gz" wins over ".gz"
+	for i := len(streamExtensions) - 1; i >= 0; i-- {
+		if name, ok := strings.CutSuffix(file, streamExtensions[i]); ok && name != "" {
+			return name
+		}
+	}
+	if name := strings.TrimSuffix(file, ".gz"); rotated(name) {
+		return name
+	}
+	return ""
+}
+
+// rotated reports whether name ends in a logrotate-style number, as in
+// app.log.1 or syslog.2.
+func rotated(name string) bool {
+	i := strings.LastIndex(name, ".")
+	if i <= 0 || name[i-1] == '/' || i == len(name)-1 {
+		return false
+	}
+	for _, c := range name[i+1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// readEvents parses events from the file starting at offset, in batches,
+// and returns the offset after the last line read. A partial last line is
+// only read when final is set; while following, it is left for the next
+// read so it arrives whole. Reading stops between batches once ctx is done.
+func readEvents(ctx context.Context, path string, offset int64, final bool, callback func([]provider.LogEvent) error) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return offset, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	gzipped := strings.HasSuffix(path, ".gz")
+	if gzipped {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return offset, err
+		}
+		defer gz.Close()
+		r = gz
+	} else if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+
+	jsonl := strings.Contains(filepath.Base(path), ".jsonl")
+	reader := bufio.NewReaderSize(r, 64*1024)
+	var batch []provider.LogEvent
+	var last *time.Time
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF && !final && !gzipped {
+			break // leave a partial last line for the next read
+		}
+		if len(line) > 0 {
+			offset += int64(len(line))
+			e := parseLine(strings.TrimRight(line, "\r\n"), jsonl)
+			if e.Timestamp == nil {
+				e.Timestamp = last // continuation lines share the previous time
+			}
+			last = e.Timestamp
+			batch = append(batch, e)
+			if len(batch) >= batchSize {
+				if err := ctx.Err(); err != nil {
+					return offset, err
+				}
+				if err := callback(batch); err != nil {
+					return offset, err
+				}
+				batch = nil
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return offset, err
+		}
+	}
+	if len(batch) > 0 {
+		if err := callback(batch); err != nil {
+			return offset, err
+		}
+	}
+	return offset, nil
+}
+
+// leadingTimestamp matches an ISO-8601-style timestamp at the start of a
+// line, with either a T or a space between date and time.
+var leadingTimestamp = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+
+// jsonLine is the shape `cwl export --format jsonl` writes.
+type jsonLine struct {
+	Timestamp *int64  `json:"timestamp"`
+	Message   *string `json:"message"`
+}
+
+// parseLine turns a line into an event, taking the timestamp from an
+// exported jsonl record or from the start of the line when present.
+func parseLine(line string, jsonl bool) provider.LogEvent {
+	if jsonl {
+		var record jsonLine
+		if json.Unmarshal([]byte(line), &record) == nil && record.Message != nil {
+			e := provider.LogEvent{Message: *record.Message}
+			if record.Timestamp != nil {
+				t := time.UnixMilli(*record.Timestamp)
+				e.Timestamp = &t
+			}
+			return e
+		}
+	}
+	e := provider.LogEvent{Message: line}
+	if m := leadingTimestamp.FindString(line); m != "" {
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999Z0700", "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999Z0700", "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05.999999999"} {
+			if t, err := time.Parse(layout, m); err == nil {
+				e.Timestamp = &t
+				break
+			}
+		}
+	}
+	return e
+}
@@ -0,0 +1,217 @@
+package local
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/derricw/cwl/provider"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func fetchAll(t *testing.T, b *Backend, group, stream string) []provider.LogEvent {
+	t.Helper()
+	var events []provider.LogEvent
+	err := b.FetchEventsStreaming(context.Background(), group, stream, func(batch []provider.LogEvent) error {
+		events = append(events, batch...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+// TestGroupsAndStreams verifies that the root (when it holds files) and each
+// subdirectory are groups, and that nested files keep slash-separated
+// stream names like those written by cwl export.
+func TestGroupsAndStreams(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "logs")
+	writeFile(t, filepath.Join(root, "2024-01-02T10-00-00.log"), "saved\n")
+	writeFile(t, filepath.Join(root, "dump", "web", "1.jsonl"), "{}\n")
+	writeFile(t, filepath.Join(root, "dump", "manifest.json"), "{}")
+	writeFile(t, filepath.Join(root, "dump", "db.log.partial"), "x")
+
+	b, err := New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups, err := b.FetchGroups(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 || groups[0].Name != "logs" || groups[1].Name != "dump" {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+
+	var streams []provider.LogStream
+	b.FetchStreamsStreaming(context.Background(), "dump", func(batch []provider.LogStream) error {
+		streams = append(streams, batch...)
+		return nil
+	})
+	if len(streams) != 1 || streams[0].Name != "web/1" {
+		t.Fatalf("unexpected streams %v", streams)
+	}
+
+	streams = nil
+	b.FetchStreamsStreaming(context.Background(), "logs", func(batch []provider.LogStream) error {
+		streams = append(streams, batch...)
+		return nil
+	})
+	if len(streams) != 1 || streams[0].Name != "2024-01-02T10-00-00" {
+		t.Fatalf("expected only files directly in the root, got %v", streams)
+	}
+
+	if _, err := b.streamPath("dump", "../../etc/passwd"); err == nil {
+		t.Fatal("expected paths outside the root to be rejected")
+	}
+}
+
+// TestTimestamps verifies timestamps parsed from line prefixes and jsonl
+// records, with continuation lines inheriting the previous timestamp.
+func TestTimestamps(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "g", "app.log"),
+		"2024-01-02 10:00:00 ERROR boom\n    at Foo.bar\n2024-01-02T10:00:01.5Z ok\n")
+	writeFile(t, filepath.Join(root, "g", "export.jsonl"),
+		`{"timestamp":1700000000000,"ingestionTime":1700000000001,"message":"hello"}`+"\n")
+	b, _ := New(root)
+
+	events := fetchAll(t, b, "g", "app")
+	if len(events) != 3 || events[0].Timestamp == nil {
+		t.Fatalf("unexpected events %v", events)
+	}
+	if events[1].Timestamp != events[0].Timestamp {
+		t.Fatal("expected continuation line to share the previous timestamp")
+	}
+	if events[2].Timestamp.UnixMilli() != events[0].Timestamp.UnixMilli()+1500 {
+		t.Fatalf("unexpected third timestamp %v", events[2].Timestamp)
+	}
+
+	events = fetchAll(t, b, "g", "export")
+	if len(events) != 1 || events[0].Message != "hello" || events[0].Timestamp.UnixMilli() != 1700000000000 {
+		t.Fatalf("unexpected jsonl event %+v", events)
+	}
+}
+
+// TestFollowAppendedLines verifies that FetchNewEvents returns only lines
+// appended since the last read, and waits for a partial line to complete.
+func TestFollowAppendedLines(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "g", "app.log")
+	writeFile(t, path, "one\n")
+	b, _ := New(root)
+	fetchAll(t, b, "g", "app")
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.WriteString("two\nthr")
+
+	events, err := b.FetchNewEvents(context.Background(), "g", "app", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Message != "two" {
+		t.Fatalf("expected [two], got %v", events)
+	}
+	f.WriteString("ee\n")
+	events, _ = b.FetchNewEvents(context.Background(), "g", "app", nil)
+	if len(events) != 1 || events[0].Message != "three" {
+		t.Fatalf("expected [three], got %v", events)
+	}
+}
+
+func TestGzipAndLastEvents(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "g", "old.log.gz")
+	os.MkdirAll(filepath.Dir(path), 0o755)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte("a\nb\nc\n"))
+	gz.Close()
+	f.Close()
+
+	b, _ := New(root)
+	events, err := b.FetchLastEvents(context.Background(), "g", "old", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Message != "b" || events[1].Message != "c" {
+		t.Fatalf("unexpected events %v", events)
+	}
+}
+
+// TestRotatedLogsAreStreams verifies that numbered rotations, gzipped or
+// not and with or without a .log extension, are listed and read as streams
+// of their own, while other gzipped files are still ignored.
+func TestRotatedLogsAreStreams(t *testing.T) {
+	root := t.TempDir()
+	writeGzip := func(path, content string) {
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gz := gzip.NewWriter(f)
+		gz.Write([]byte(content))
+		gz.Close()
+		f.Close()
+	}
+	writeFile(t, filepath.Join(root, "var", "app.log"), "live\n")
+	writeFile(t, filepath.Join(root, "var", "app.log.1"), "first\n")
+	writeFile(t, filepath.Join(root, "var", "v1.2.log"), "versioned\n")
+	writeGzip(filepath.Join(root, "var", "app.log.2.gz"), "older\n")
+	writeGzip(filepath.Join(root, "var", "syslog.3.gz"), "kernel\n")
+	writeGzip(filepath.Join(root, "var", "backup.tar.gz"), "not a log\n")
+
+	b, _ := New(root)
+	var names []string
+	b.FetchStreamsStreaming(context.Background(), "var", func(batch []provider.LogStream) error {
+		for _, s := range batch {
+			names = append(names, s.Name)
+		}
+		return nil
+	})
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "app,app.log.1,app.log.2,syslog.3,v1.2" {
+		t.Fatalf("streams = %q", got)
+	}
+	for stream, want := range map[string]string{"app": "live", "app.log.1": "first", "app.log.2": "older", "syslog.3": "kernel", "v1.2": "versioned"} {
+		if events := fetchAll(t, b, "var", stream); len(events) != 1 || events[0].Message != want {
+			t.Errorf("%s: events = %v, want %q", stream, events, want)
+		}
+	}
+}
+
+// TestOpenSourceURI verifies that file:// sources accept absolute paths.
+func TestOpenSourceURI(t *testing.T) {
+	dir := t.TempDir()
+	b, err := provider.Open("file://"+dir, provider.OpenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.(*Backend).root; got != dir {
+		t.Errorf("root = %q, want %q", got, dir)
+	}
+	if _, err := provider.Open("file://"+filepath.Join(dir, "missing"), provider.OpenOptions{}); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
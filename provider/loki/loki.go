@@ -0,0 +1,329 @@
+// Package loki implements provider.Backend for Grafana Loki's HTTP API.
+// Maps: values of one label (app by default) → groups, label sets → streams,
+// log lines → events.
+//
+// Streams are named by their LogQL selector, e.g. {app="web", pod="web-1"},
+// so a name can be sent straight back to Loki as a query. Following polls
+// query_range rather than holding open a /loki/api/v1/tail websocket, which
+// fits the TUI's FetchNewEvents polling model.
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/derricw/cwl/provider"
+)
+
+// DefaultLabel is the label whose values become groups.
+const DefaultLabel = "app"
+
+// DefaultLookback is how far back streams and events are searched, since
+// Loki queries always cover a time range.
+const DefaultLookback = 24 * time.Hour
+
+// defaultPageSize is the number of lines requested per query_range call.
+const defaultPageSize = 5000
+
+type Backend struct {
+	baseURL string
+	client  *http.Client
+	// Label is the label whose values are listed as groups.
+	Label string
+	// OrgID is sent as X-Scope-OrgID to multi-tenant Loki clusters.
+	OrgID string
+	// Lookback bounds how far back streams and events are searched.
+	Lookback time.Duration
+	user     string
+	password string
+	pageSize int
+	now      func() time.Time
+}
+
+func init() {
+	provider.Register("loki+http", open)
+	provider.Register("loki+https", open)
+}
+
+// open handles loki+https://[user:password@]host[/prefix][?label=app&org=tenant&lookback=72h].
+// Credentials in the URI are sent as basic auth.
+func open(uri string, opts provider.OpenOptions) (provider.Backend, error) {
+	scheme, rest, _ := strings.Cut(uri, "://")
+	u, err := url.Parse(strings.TrimPrefix(strings.ToLower(scheme), "loki+") + "://" + rest)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	u.RawQuery = ""
+	b := New(u.Scheme + "://" + u.Host + u.Path)
+	if u.User != nil {
+		b.user = u.User.Username()
+		b.password, _ = u.User.Password()
+	}
+	if label := query.Get("label"); label != "" {
+		b.Label = label
+	}
+	b.OrgID = query.Get("org")
+	if lookback := query.Get("lookback"); lookback != "" {
+		if b.Lookback, err = time.ParseDuration(lookback); err != nil {
+			return nil, fmt.Errorf("invalid lookback %q: %w", lookback, err)
+		}
+	}
+	return b, nil
+}
+
+// New creates a backend for the Loki server at baseURL.
+func New(baseURL string) *Backend {
+	return &Backend{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		client:   &http.Client{Timeout: 30 * time.Second},
+		Label:    DefaultLabel,
+		Lookback: DefaultLookback,
+		pageSize: defaultPageSize,
+		now:      time.Now,
+	}
+}
+
+// API response types
+
+type labelValuesResp struct {
+	Data []string `json:"data"`
+}
+
+type seriesResp struct {
+	Data []map[string]string `json:"data"`
+}
+
+type queryRangeResp struct {
+	Data struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"` // [unix nanoseconds, line]
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// Backend interface implementation
+
+func (b *Backend) FetchGroups(ctx context.Context, pattern string) ([]provider.LogGroup, error) {
+	params := b.window()
+	var resp labelValuesResp
+	if err := b.get(ctx, "/loki/api/v1/label/"+url.PathEscape(b.Label)+"/values", params, &resp); err != nil {
+		return nil, err
+	}
+	var groups []provider.LogGroup
+	for _, value := range resp.Data {
+		if pattern != "" && !strings.Contains(strings.ToLower(value), strings.ToLower(pattern)) {
+			continue
+		}
+		groups = append(groups, provider.LogGroup{Name: value, Desc: b.Label + "=" + value})
+	}
+	return groups, nil
+}
+
+func (b *Backend) FetchStreamsStreaming(ctx context.Context, group string, callback func([]provider.LogStream) error) error {
+	params := b.window()
+	params.Set("match[]", selector(map[string]string{b.Label: group}))
+	var resp seriesResp
+	if err := b.get(ctx, "/loki/api/v1/series", params, &resp); err != nil {
+		return err
+	}
+	if len(resp.Data) == 0 {
+		return nil
+	}
+	names := make([]string, len(resp.Data))
+	for i, labels := range resp.Data {
+		names[i] = selector(labels)
+	}
+	sort.Strings(names)
+	streams := make([]provider.LogStream, len(names))
+	for i, name := range names {
+		streams[i] = provider.LogStream{Name: name}
+	}
+	return callback(streams)
+}
+
+// FetchEventsStreaming pages forward through the lookback window. Each page
+// starts at the last page's final timestamp, since Loki's start is
+// inclusive; lines already seen at that timestamp are skipped. Only when more
+// than a page of lines share one nanosecond can some of them be missed.
+func (b *Backend) FetchEventsStreaming(ctx context.Context, group, stream string, callback func([]provider.LogEvent) error) error {
+	end := b.now()
+	start := end.Add(-b.Lookback).UnixNano()
+	seen := map[entry]bool{}
+	for {
+		page, err := b.queryRange(ctx, stream, start, end.UnixNano(), b.pageSize, "forward")
+		if err != nil {
+			return err
+		}
+		var fresh []entry
+		for _, e := range page {
+			if !(e.ts == start && seen[e]) {
+				fresh = append(fresh, e)
+			}
+		}
+		if len(fresh) > 0 {
+			if err := callback(events(fresh)); err != nil {
+				return err
+			}
+		}
+		if len(page) < b.pageSize {
+			return nil
+		}
+		last := page[len(page)-1].ts
+		if last == start {
+			// a whole page shares one timestamp; step past it rather than
+			// request the same page forever
+			start, seen = last+1, map[entry]bool{}
+			continue
+		}
+		seen = map[entry]bool{}
+		for _, e := range page {
+			if e.ts == last {
+				seen[e] = true
+			}
+		}
+		start = last
+	}
+}
+
+func (b *Backend) FetchLastEvents(ctx context.Context, group, stream string, limit int) ([]provider.LogEvent, error) {
+	end := b.now()
+	page, err := b.queryRange(ctx, stream, end.Add(-b.Lookback).UnixNano(), end.UnixNano(), limit, "backward")
+	if err != nil {
+		return nil, err
+	}
+	return events(page), nil
+}
+
+// FetchNewEvents polls for lines after since, up to one page per poll.
+func (b *Backend) FetchNewEvents(ctx context.Context, group, stream string, since *time.Time) ([]provider.LogEvent, error) {
+	if since == nil {
+		return nil, nil
+	}
+	page, err := b.queryRange(ctx, stream, since.UnixNano()+1, b.now().UnixNano(), b.pageSize, "forward")
+	if err != nil {
+		return nil, err
+	}
+	return events(page), nil
+}
+
+// Capabilities: Loki streams grow, can be saved and LogQL is a query
+// language, but label values are filtered client-side.
+
+func (b *Backend) SupportsFollow() bool       { return true }
+func (b *Backend) SupportsQuery() bool        { return true }
+func (b *Backend) SupportsServerFilter() bool { return false }
+func (b *Backend) SupportsWrite() bool        { return true }
+
+// Helpers
+
+// entry is a log line at a Unix nanosecond timestamp.
+type entry struct {
+	ts   int64
+	line string
+}
+
+func events(entries []entry) []provider.LogEvent {
+	result := make([]provider.LogEvent, len(entries))
+	for i, e := range entries {
+		t := time.Unix(0, e.ts)
+		result[i] = provider.LogEvent{Message: e.line, Timestamp: &t}
+	}
+	return result
+}
+
+// selector renders labels as a LogQL stream selector with sorted keys, so
+// the same label set always gets the same name.
+func selector(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + strconv.Quote(labels[k])
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// window returns the start and end parameters covering the lookback.
+func (b *Backend) window() url.Values {
+	end := b.now()
+	return url.Values{
+		"start": {strconv.FormatInt(end.Add(-b.Lookback).UnixNano(), 10)},
+		"end":   {strconv.FormatInt(end.UnixNano(), 10)},
+	}
+}
+
+// queryRange runs a log query and returns its lines oldest first. A selector
+// can match several streams, whose lines are merged by timestamp.
+func (b *Backend) queryRange(ctx context.Context, query string, start, end int64, limit int, direction string) ([]entry, error) {
+	params := url.Values{
+		"query":     {query},
+		"start":     {strconv.FormatInt(start, 10)},
+		"end":       {strconv.FormatInt(end, 10)},
+		"limit":     {strconv.Itoa(limit)},
+		"direction": {direction},
+	}
+	var resp queryRangeResp
+	if err := b.get(ctx, "/loki/api/v1/query_range", params, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data.ResultType != "" && resp.Data.ResultType != "streams" {
+		return nil, fmt.Errorf("loki returned %s, not log lines: is %q a log query?", resp.Data.ResultType, query)
+	}
+	var entries []entry
+	for _, r := range resp.Data.Result {
+		for _, v := range r.Values {
+			ts, err := strconv.ParseInt(v[0], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp %q from loki", v[0])
+			}
+			entries = append(entries, entry{ts: ts, line: v[1]})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].ts < entries[j].ts })
+	// a backward query returns the newest lines; keep only the limit
+	if len(entries) > limit {
+		if direction == "backward" {
+			entries = entries[len(entries)-limit:]
+		} else {
+			entries = entries[:limit]
+		}
+	}
+	return entries, nil
+}
+
+func (b *Backend) get(ctx context.Context, path string, params url.Values, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.baseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if b.user != "" {
+		req.SetBasicAuth(b.user, b.password)
+	}
+	if b.OrgID != "" {
+		req.Header.Set("X-Scope-OrgID", b.OrgID)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("loki API error: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,211 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/derricw/cwl/provider"
+)
+
+var testNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeLoki serves the read endpoints cwl uses from an in-memory set of
+// streams, honoring start (inclusive), end (exclusive), limit and direction.
+type fakeLoki struct {
+	streams map[string][]entry // selector → lines, oldest first
+	labels  map[string]map[string]string
+	queries int
+	headers http.Header
+}
+
+func newFakeLoki(t *testing.T) (*fakeLoki, *Backend) {
+	f := &fakeLoki{streams: map[string][]entry{}, labels: map[string]map[string]string{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	b := New(srv.URL)
+	b.now = func() time.Time { return testNow }
+	return f, b
+}
+
+func (f *fakeLoki) add(labels map[string]string, lines ...entry) {
+	name := selector(labels)
+	f.labels[name] = labels
+	f.streams[name] = append(f.streams[name], lines...)
+}
+
+func (f *fakeLoki) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.headers = r.Header
+	q := r.URL.Query()
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/loki/api/v1/label/"):
+		label := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/loki/api/v1/label/"), "/values")
+		values := map[string]bool{}
+		for _, labels := range f.labels {
+			if v, ok := labels[label]; ok {
+				values[v] = true
+			}
+		}
+		var data []string
+		for v := range values {
+			data = append(data, v)
+		}
+		sort.Strings(data)
+		json.NewEncoder(w).Encode(map[string]any{"status": "success", "data": data})
+	case r.URL.Path == "/loki/api/v1/series":
+		want := strings.Trim(q.Get("match[]"), "{}")
+		var data []map[string]string
+		for name, labels := range f.labels {
+			if strings.Contains(name, want) {
+				data = append(data, labels)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"status": "success", "data": data})
+	case r.URL.Path == "/loki/api/v1/query_range":
+		f.queries++
+		start, _ := strconv.ParseInt(q.Get("start"), 10, 64)
+		end, _ := strconv.ParseInt(q.Get("end"), 10, 64)
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		lines := f.streams[q.Get("query")]
+		var picked [][2]string
+		if q.Get("direction") == "backward" {
+			for i := len(lines) - 1; i >= 0 && len(picked) < limit; i-- {
+				if lines[i].ts >= start && lines[i].ts < end {
+					picked = append(picked, [2]string{strconv.FormatInt(lines[i].ts, 10), lines[i].line})
+				}
+			}
+		} else {
+			for _, e := range lines {
+				if e.ts >= start && e.ts < end && len(picked) < limit {
+					picked = append(picked, [2]string{strconv.FormatInt(e.ts, 10), e.line})
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"status": "success", "data": map[string]any{
+			"resultType": "streams",
+			"result":     []map[string]any{{"stream": f.labels[q.Get("query")], "values": picked}},
+		}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func at(d time.Duration, line string) entry {
+	return entry{ts: testNow.Add(-d).UnixNano(), line: line}
+}
+
+func messages(events []provider.LogEvent) string {
+	msgs := make([]string, len(events))
+	for i, e := range events {
+		msgs[i] = e.Message
+	}
+	return strings.Join(msgs, ",")
+}
+
+// TestGroupsAndStreams verifies that values of the group label are listed
+// as groups, and each group's label sets as selector-named streams.
+func TestGroupsAndStreams(t *testing.T) {
+	f, b := newFakeLoki(t)
+	f.add(map[string]string{"app": "web", "pod": "web-1"})
+	f.add(map[string]string{"app": "web", "pod": "web-0"})
+	f.add(map[string]string{"app": "worker"})
+
+	groups, err := b.FetchGroups(context.Background(), "we")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0].Name != "web" || groups[0].Desc != "app=web" {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+
+	var names []string
+	err = b.FetchStreamsStreaming(context.Background(), "web", func(streams []provider.LogStream) error {
+		for _, s := range streams {
+			names = append(names, s.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{app="web", pod="web-0"}|{app="web", pod="web-1"}`
+	if got := strings.Join(names, "|"); got != want {
+		t.Errorf("streams = %s, want %s", got, want)
+	}
+}
+
+// TestEventsPaginateByTimestamp verifies that events are paged forward by
+// timestamp without repeating or dropping lines that share the timestamp
+// a page ends on.
+func TestEventsPaginateByTimestamp(t *testing.T) {
+	f, b := newFakeLoki(t)
+	b.pageSize = 2
+	labels := map[string]string{"app": "web"}
+	f.add(labels, at(3*time.Hour, "a"), at(2*time.Hour, "b"), at(2*time.Hour, "c"), at(time.Hour, "d"), at(time.Minute, "e"))
+	f.add(labels, at(48*time.Hour, "too old"))
+
+	var batches []string
+	err := b.FetchEventsStreaming(context.Background(), "web", selector(labels), func(events []provider.LogEvent) error {
+		batches = append(batches, messages(events))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(batches, ","); got != "a,b,c,d,e" {
+		t.Errorf("events = %s, want a,b,c,d,e (batches %q)", got, batches)
+	}
+}
+
+// TestLastAndNewEvents verifies the preview returns the newest lines oldest
+// first, and polling returns only lines after the given time.
+func TestLastAndNewEvents(t *testing.T) {
+	f, b := newFakeLoki(t)
+	labels := map[string]string{"app": "web"}
+	f.add(labels, at(3*time.Minute, "a"), at(2*time.Minute, "b"), at(time.Minute, "c"))
+	stream := selector(labels)
+
+	last, err := b.FetchLastEvents(context.Background(), "web", stream, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(last); got != "b,c" {
+		t.Errorf("last events = %s, want b,c", got)
+	}
+
+	newEvents, err := b.FetchNewEvents(context.Background(), "web", stream, last[0].Timestamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := messages(newEvents); got != "c" {
+		t.Errorf("new events = %s, want c", got)
+	}
+}
+
+// TestOpenSourceURI verifies that a loki+https source sets the base URL,
+// basic auth, tenant, group label and lookback, and that they are sent.
+func TestOpenSourceURI(t *testing.T) {
+	b, err := provider.Open("loki+https://u:p@loki.example.com/prefix?label=service&org=team-a&lookback=72h", provider.OpenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := b.(*Backend)
+	if lb.baseURL != "https://loki.example.com/prefix" || lb.Label != "service" || lb.OrgID != "team-a" || lb.Lookback != 72*time.Hour {
+		t.Fatalf("unexpected backend %+v", lb)
+	}
+
+	f, local := newFakeLoki(t)
+	local.user, local.password, local.OrgID = lb.user, lb.password, lb.OrgID
+	if _, err := local.FetchGroups(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+	if f.headers.Get("X-Scope-OrgID") != "team-a" || !strings.HasPrefix(f.headers.Get("Authorization"), "Basic ") {
+		t.Errorf("missing tenant or auth headers: %v", f.headers)
+	}
+}
@@ -2,9 +2,11 @@
 // Maps: experiments → groups, runs → streams, metric history → events.
 //
 // Supports two connection modes:
-//   - Direct: connect to a self-hosted MLflow server via --mlflow-url
+//   - Direct: connect to a self-hosted MLflow server via --mlflow-url or
+//     --source mlflow+https://host
 //   - SageMaker: connect via presigned URL from a SageMaker-managed MLflow
-//     tracking server via --mlflow-arn (uses AWS credentials)
+//     tracking server via --mlflow-arn or --source mlflow+sagemaker://<arn>
+//     (uses AWS credentials)
 package mlflow
 
 import (
@@ -13,6 +15,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/cookiejar"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,6 +33,22 @@ type Backend struct {
 	serverName string
 }
 
+func init() {
+	provider.Register("mlflow+http", open)
+	provider.Register("mlflow+https", open)
+	provider.Register("mlflow+sagemaker", open)
+}
+
+// open strips the "mlflow+" prefix: what remains is either the server URL
+// or, for mlflow+sagemaker://, the tracking server ARN.
+func open(uri string, opts provider.OpenOptions) (provider.Backend, error) {
+	scheme, rest, _ := strings.Cut(uri, "://")
+	if strings.EqualFold(scheme, "mlflow+sagemaker") {
+		return NewFromSageMakerARN(rest, opts.Profile)
+	}
+	return New(strings.TrimPrefix(strings.ToLower(scheme), "mlflow+") + "://" + rest), nil
+}
+
 // New creates a backend for a direct MLflow server URL.
 func New(baseURL string) *Backend {
 	return &Backend{
@@ -114,8 +133,17 @@ func NewFromSageMakerARN(arn, awsProfile string) (*Backend, error) {
 // API response types
 
 type experiment struct {
-	ExperimentID string `json:"experiment_id"`
-	Name         string `json:"name"`
+	ExperimentID     string `json:"experiment_id"`
+	Name             string `json:"name"`
+	ArtifactLocation string `json:"artifact_location"`
+	LifecycleStage   string `json:"lifecycle_stage"`
+	CreationTime     int64  `json:"creation_time"`
+	Tags             []tag  `json:"tags"`
+}
+
+type tag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
 }
 
 type searchExperimentsResp struct {
@@ -169,7 +197,7 @@ type metricHistoryResp struct {
 
 // Backend interface implementation
 
-func (b *Backend) FetchGroups(pattern string) ([]provider.LogGroup, error) {
+func (b *Backend) FetchGroups(ctx context.Context, pattern string) ([]provider.LogGroup, error) {
 	var all []provider.LogGroup
 	var pageToken string
 	for {
@@ -181,14 +209,11 @@ func (b *Backend) FetchGroups(pattern string) ([]provider.LogGroup, error) {
 			body["filter"] = fmt.Sprintf("name ILIKE '%%%s%%'", pattern)
 		}
 		var resp searchExperimentsResp
-		if err := b.post("/api/2.0/mlflow/experiments/search", body, &resp); err != nil {
+		if err := b.post(ctx, "/api/2.0/mlflow/experiments/search", body, &resp); err != nil {
 			return nil, err
 		}
 		for _, exp := range resp.Experiments {
-			all = append(all, provider.LogGroup{
-				Name: exp.Name,
-				Desc: "experiment_id=" + exp.ExperimentID,
-			})
+			all = append(all, convertExperiment(exp))
 		}
 		if resp.NextPageToken == "" {
 			break
@@ -198,8 +223,8 @@ func (b *Backend) FetchGroups(pattern string) ([]provider.LogGroup, error) {
 	return all, nil
 }
 
-func (b *Backend) FetchStreamsStreaming(group string, callback func([]provider.LogStream) error) error {
-	expID, err := b.getExperimentID(group)
+func (b *Backend) FetchStreamsStreaming(ctx context.Context, group string, callback func([]provider.LogStream) error) error {
+	expID, err := b.getExperimentID(ctx, group)
 	if err != nil {
 		return err
 	}
@@ -214,7 +239,7 @@ func (b *Backend) FetchStreamsStreaming(group string, callback func([]provider.L
 			body["page_token"] = pageToken
 		}
 		var resp searchRunsResp
-		if err := b.post("/api/2.0/mlflow/runs/search", body, &resp); err != nil {
+		if err := b.post(ctx, "/api/2.0/mlflow/runs/search", body, &resp); err != nil {
 			return err
 		}
 		if len(resp.Runs) > 0 {
@@ -227,6 +252,11 @@ func (b *Backend) FetchStreamsStreaming(group string, callback func([]provider.L
 				streams[i] = provider.LogStream{Name: name}
 				if r.Info.StartTime > 0 {
 					t := time.UnixMilli(r.Info.StartTime)
+					streams[i].FirstEventTime = &t
+					streams[i].LastEventTime = &t
+				}
+				if r.Info.EndTime > 0 {
+					t := time.UnixMilli(r.Info.EndTime)
 					streams[i].LastEventTime = &t
 				}
 			}
@@ -242,12 +272,12 @@ func (b *Backend) FetchStreamsStreaming(group string, callback func([]provider.L
 	return nil
 }
 
-func (b *Backend) FetchEventsStreaming(group, stream string, callback func([]provider.LogEvent) error) error {
-	runID, err := b.resolveRunID(group, stream)
+func (b *Backend) FetchEventsStreaming(ctx context.Context, group, stream string, callback func([]provider.LogEvent) error) error {
+	runID, err := b.resolveRunID(ctx, group, stream)
 	if err != nil {
 		return err
 	}
-	keys, err := b.getMetricKeys(runID)
+	keys, err := b.getMetricKeys(ctx, runID)
 	if err != nil {
 		return err
 	}
@@ -255,19 +285,19 @@ func (b *Backend) FetchEventsStreaming(group, stream string, callback func([]pro
 		return fmt.Errorf("no metrics found for run %s", runID)
 	}
 	for _, key := range keys {
-		if err := b.fetchMetricHistory(runID, key, callback); err != nil {
+		if err := b.fetchMetricHistory(ctx, runID, stream, key, callback); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (b *Backend) FetchLastEvents(group, stream string, limit int) ([]provider.LogEvent, error) {
-	runID, err := b.resolveRunID(group, stream)
+func (b *Backend) FetchLastEvents(ctx context.Context, group, stream string, limit int) ([]provider.LogEvent, error) {
+	runID, err := b.resolveRunID(ctx, group, stream)
 	if err != nil {
 		return nil, err
 	}
-	keys, err := b.getMetricKeys(runID)
+	keys, err := b.getMetricKeys(ctx, runID)
 	if err != nil {
 		return nil, err
 	}
@@ -277,7 +307,7 @@ func (b *Backend) FetchLastEvents(group, stream string, limit int) ([]provider.L
 	// For preview, just show latest values for each metric
 	var events []provider.LogEvent
 	for _, key := range keys {
-		err := b.fetchMetricHistory(runID, key, func(batch []provider.LogEvent) error {
+		err := b.fetchMetricHistory(ctx, runID, stream, key, func(batch []provider.LogEvent) error {
 			events = append(events, batch...)
 			return nil
 		})
@@ -291,18 +321,56 @@ func (b *Backend) FetchLastEvents(group, stream string, limit int) ([]provider.L
 	return events, nil
 }
 
-func (b *Backend) FetchNewEvents(group, stream string, since *time.Time) ([]provider.LogEvent, error) {
+func (b *Backend) FetchNewEvents(ctx context.Context, group, stream string, since *time.Time) ([]provider.LogEvent, error) {
 	// MLflow metrics are immutable once logged — no new events after initial fetch
 	return nil, nil
 }
 
+// Capabilities: metrics are immutable once logged, so there is nothing to
+// follow, and there is no query language. Experiment search filters by name
+// on the server, and runs can be saved.
+
+func (b *Backend) SupportsFollow() bool       { return false }
+func (b *Backend) SupportsQuery() bool        { return false }
+func (b *Backend) SupportsServerFilter() bool { return true }
+func (b *Backend) SupportsWrite() bool        { return true }
+
 // Helpers
 
+// convertExperiment maps an experiment to a group, keeping its tags and
+// where its artifacts are stored.
+func convertExperiment(exp experiment) provider.LogGroup {
+	group := provider.LogGroup{
+		Name: exp.Name,
+		Desc: "experiment_id=" + exp.ExperimentID,
+		Metadata: map[string]string{
+			"experiment_id": exp.ExperimentID,
+		},
+	}
+	if exp.ArtifactLocation != "" {
+		group.Metadata["artifact_location"] = exp.ArtifactLocation
+	}
+	if exp.LifecycleStage != "" {
+		group.Metadata["lifecycle_stage"] = exp.LifecycleStage
+	}
+	if exp.CreationTime > 0 {
+		t := time.UnixMilli(exp.CreationTime)
+		group.CreatedTime = &t
+	}
+	if len(exp.Tags) > 0 {
+		group.Tags = make(map[string]string, len(exp.Tags))
+		for _, t := range exp.Tags {
+			group.Tags[t.Key] = t.Value
+		}
+	}
+	return group
+}
+
 // getMetricKeys fetches the run and returns all metric keys it has logged.
-func (b *Backend) getMetricKeys(runID string) ([]string, error) {
+func (b *Backend) getMetricKeys(ctx context.Context, runID string) ([]string, error) {
 	var resp getRunResp
 	url := fmt.Sprintf("/api/2.0/mlflow/runs/get?run_id=%s", runID)
-	if err := b.get(url, &resp); err != nil {
+	if err := b.get(ctx, url, &resp); err != nil {
 		return nil, err
 	}
 	keys := make([]string, len(resp.Run.Data.Metrics))
@@ -312,19 +380,19 @@ func (b *Backend) getMetricKeys(runID string) ([]string, error) {
 	return keys, nil
 }
 
-func (b *Backend) getExperimentID(name string) (string, error) {
+func (b *Backend) getExperimentID(ctx context.Context, name string) (string, error) {
 	var resp struct {
 		Experiment experiment `json:"experiment"`
 	}
 	url := fmt.Sprintf("/api/2.0/mlflow/experiments/get-by-name?experiment_name=%s", name)
-	if err := b.get(url, &resp); err != nil {
+	if err := b.get(ctx, url, &resp); err != nil {
 		return "", fmt.Errorf("experiment %q not found: %w", name, err)
 	}
 	return resp.Experiment.ExperimentID, nil
 }
 
-func (b *Backend) resolveRunID(group, stream string) (string, error) {
-	expID, err := b.getExperimentID(group)
+func (b *Backend) resolveRunID(ctx context.Context, group, stream string) (string, error) {
+	expID, err := b.getExperimentID(ctx, group)
 	if err != nil {
 		return "", err
 	}
@@ -334,7 +402,7 @@ func (b *Backend) resolveRunID(group, stream string) (string, error) {
 		"max_results":    1,
 	}
 	var resp searchRunsResp
-	if err := b.post("/api/2.0/mlflow/runs/search", body, &resp); err != nil {
+	if err := b.post(ctx, "/api/2.0/mlflow/runs/search", body, &resp); err != nil {
 		return "", err
 	}
 	if len(resp.Runs) == 0 {
@@ -343,7 +411,9 @@ func (b *Backend) resolveRunID(group, stream string) (string, error) {
 	return resp.Runs[0].Info.RunID, nil
 }
 
-func (b *Backend) fetchMetricHistory(runID, metricKey string, callback func([]provider.LogEvent) error) error {
+// fetchMetricHistory lists a metric's values as events from stream, the
+// run's name as the TUI knows it.
+func (b *Backend) fetchMetricHistory(ctx context.Context, runID, stream, metricKey string, callback func([]provider.LogEvent) error) error {
 	var pageToken string
 	for {
 		url := fmt.Sprintf("/api/2.0/mlflow/metrics/get-history?run_id=%s&metric_key=%s&max_results=10000", runID, metricKey)
@@ -351,7 +421,7 @@ func (b *Backend) fetchMetricHistory(runID, metricKey string, callback func([]pr
 			url += "&page_token=" + pageToken
 		}
 		var resp metricHistoryResp
-		if err := b.get(url, &resp); err != nil {
+		if err := b.get(ctx, url, &resp); err != nil {
 			return err
 		}
 		if len(resp.Metrics) > 0 {
@@ -361,6 +431,12 @@ func (b *Backend) fetchMetricHistory(runID, metricKey string, callback func([]pr
 				events[i] = provider.LogEvent{
 					Message:   fmt.Sprintf("step=%d %s=%g", m.Step, m.Key, m.Value),
 					Timestamp: &t,
+					Stream:    stream,
+					Fields: map[string]string{
+						"key":   m.Key,
+						"value": strconv.FormatFloat(m.Value, 'g', -1, 64),
+						"step":  strconv.FormatInt(m.Step, 10),
+					},
 				}
 			}
 			if err := callback(events); err != nil {
@@ -375,22 +451,29 @@ func (b *Backend) fetchMetricHistory(runID, metricKey string, callback func([]pr
 	return nil
 }
 
-func (b *Backend) post(path string, body interface{}, result interface{}) error {
+func (b *Backend) post(ctx context.Context, path string, body interface{}, result interface{}) error {
 	data, err := json.Marshal(body)
 	if err != nil {
 		return err
 	}
 	for attempt := 0; attempt < 4; attempt++ {
 		if attempt > 0 {
-			time.Sleep(time.Duration(attempt*attempt) * b.retryDelay) // 1x, 4x, 9x
+			if err := sleep(ctx, time.Duration(attempt*attempt)*b.retryDelay); err != nil { // 1x, 4x, 9x
+				return err
+			}
 		}
-		resp, err := b.client.Post(b.baseURL+path, "application/json", strings.NewReader(string(data)))
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.baseURL+path, strings.NewReader(string(data)))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := b.client.Do(req)
 		if err != nil {
 			return err
 		}
 		if resp.StatusCode == http.StatusForbidden {
 			resp.Body.Close()
-			if err := b.reauth(); err != nil {
+			if err := b.reauth(ctx); err != nil {
 				return err
 			}
 			continue
@@ -408,18 +491,24 @@ func (b *Backend) post(path string, body interface{}, result interface{}) error
 	return fmt.Errorf("mlflow API error: too many retries")
 }
 
-func (b *Backend) get(path string, result interface{}) error {
+func (b *Backend) get(ctx context.Context, path string, result interface{}) error {
 	for attempt := 0; attempt < 4; attempt++ {
 		if attempt > 0 {
-			time.Sleep(time.Duration(attempt*attempt) * b.retryDelay)
+			if err := sleep(ctx, time.Duration(attempt*attempt)*b.retryDelay); err != nil {
+				return err
+			}
 		}
-		resp, err := b.client.Get(b.baseURL + path)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.baseURL+path, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := b.client.Do(req)
 		if err != nil {
 			return err
 		}
 		if resp.StatusCode == http.StatusForbidden {
 			resp.Body.Close()
-			if err := b.reauth(); err != nil {
+			if err := b.reauth(ctx); err != nil {
 				return err
 			}
 			continue
@@ -439,11 +528,11 @@ func (b *Backend) get(path string, result interface{}) error {
 
 // reauth generates a new presigned URL and re-establishes the session.
 // Only applicable for SageMaker-backed connections.
-func (b *Backend) reauth() error {
+func (b *Backend) reauth(ctx context.Context) error {
 	if b.smClient == nil {
 		return fmt.Errorf("session expired and no SageMaker client available for re-auth")
 	}
-	resp, err := b.smClient.CreatePresignedMlflowTrackingServerUrl(context.TODO(),
+	resp, err := b.smClient.CreatePresignedMlflowTrackingServerUrl(ctx,
 		&sagemaker.CreatePresignedMlflowTrackingServerUrlInput{
 			TrackingServerName: &b.serverName,
 		},
@@ -451,10 +540,26 @@ func (b *Backend) reauth() error {
 	if err != nil {
 		return fmt.Errorf("failed to refresh presigned URL: %w", err)
 	}
-	authResp, err := b.client.Get(*resp.AuthorizedUrl)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *resp.AuthorizedUrl, nil)
+	if err != nil {
+		return err
+	}
+	authResp, err := b.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to re-authenticate: %w", err)
 	}
 	authResp.Body.Close()
 	return nil
 }
+
+// sleep waits for d, or returns early with the context's error.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
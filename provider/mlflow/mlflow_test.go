@@ -1,6 +1,7 @@
 package mlflow
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -24,7 +25,7 @@ func TestFetchGroupsParsesExperiments(t *testing.T) {
 	defer srv.Close()
 
 	b := New(srv.URL)
-	groups, err := b.FetchGroups("")
+	groups, err := b.FetchGroups(context.Background(), "")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -39,6 +40,39 @@ func TestFetchGroupsParsesExperiments(t *testing.T) {
 	}
 }
 
+// TestFetchGroupsKeepsExperimentDetails verifies that an experiment's
+// creation time, tags and artifact location are carried onto its group.
+func TestFetchGroupsKeepsExperimentDetails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(searchExperimentsResp{
+			Experiments: []experiment{{
+				ExperimentID:     "7",
+				Name:             "tuned",
+				ArtifactLocation: "s3://bucket/7",
+				LifecycleStage:   "active",
+				CreationTime:     1700000000000,
+				Tags:             []tag{{Key: "team", Value: "ml"}},
+			}},
+		})
+	}))
+	defer srv.Close()
+
+	groups, err := New(srv.URL).FetchGroups(context.Background(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	g := groups[0]
+	if g.CreatedTime == nil || g.CreatedTime.UnixMilli() != 1700000000000 {
+		t.Fatalf("unexpected created time: %v", g.CreatedTime)
+	}
+	if g.Tags["team"] != "ml" {
+		t.Fatalf("unexpected tags: %v", g.Tags)
+	}
+	if g.Metadata["artifact_location"] != "s3://bucket/7" || g.Metadata["experiment_id"] != "7" {
+		t.Fatalf("unexpected metadata: %v", g.Metadata)
+	}
+}
+
 // TestFetchGroupsWithPattern verifies that the filter parameter is passed
 // to the API when a pattern is provided.
 func TestFetchGroupsWithPattern(t *testing.T) {
@@ -50,7 +84,7 @@ func TestFetchGroupsWithPattern(t *testing.T) {
 	defer srv.Close()
 
 	b := New(srv.URL)
-	b.FetchGroups("test")
+	b.FetchGroups(context.Background(), "test")
 
 	filter, ok := receivedBody["filter"].(string)
 	if !ok || filter != "name ILIKE '%test%'" {
@@ -75,7 +109,7 @@ func TestRetryOn429(t *testing.T) {
 
 	b := New(srv.URL)
 	b.retryDelay = 0
-	groups, err := b.FetchGroups("")
+	groups, err := b.FetchGroups(context.Background(), "")
 	if err != nil {
 		t.Fatalf("expected success after retries, got: %v", err)
 	}
@@ -97,7 +131,7 @@ func TestMaxRetriesExhausted(t *testing.T) {
 
 	b := New(srv.URL)
 	b.retryDelay = 0
-	_, err := b.FetchGroups("")
+	_, err := b.FetchGroups(context.Background(), "")
 	if err == nil {
 		t.Fatal("expected error after max retries")
 	}
@@ -125,7 +159,7 @@ func TestGetMetricKeys(t *testing.T) {
 	defer srv.Close()
 
 	b := New(srv.URL)
-	keys, err := b.getMetricKeys("abc")
+	keys, err := b.getMetricKeys(context.Background(), "abc")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -155,7 +189,7 @@ func TestFetchMetricHistory(t *testing.T) {
 
 	b := New(srv.URL)
 	var events []provider.LogEvent
-	err := b.fetchMetricHistory("abc", "loss", func(batch []provider.LogEvent) error {
+	err := b.fetchMetricHistory(context.Background(), "abc", "run-1", "loss", func(batch []provider.LogEvent) error {
 		events = append(events, batch...)
 		return nil
 	})
@@ -171,6 +205,12 @@ func TestFetchMetricHistory(t *testing.T) {
 	if events[1].Message != "step=2 loss=0.3" {
 		t.Fatalf("unexpected message: %q", events[1].Message)
 	}
+	if events[1].Stream != "run-1" {
+		t.Fatalf("expected stream 'run-1', got %q", events[1].Stream)
+	}
+	if f := events[1].Fields; f["key"] != "loss" || f["value"] != "0.3" || f["step"] != "2" {
+		t.Fatalf("unexpected fields: %v", f)
+	}
 }
 
 // TestParseARN verifies that NewFromSageMakerARN rejects invalid ARNs
@@ -186,3 +226,15 @@ func TestParseARNInvalid(t *testing.T) {
 		t.Fatal("expected error for invalid resource format")
 	}
 }
+
+// TestOpenSourceURI verifies that mlflow+http(s) sources connect to the URL
+// after the prefix.
+func TestOpenSourceURI(t *testing.T) {
+	b, err := provider.Open("mlflow+https://mlflow.example.com/base/", provider.OpenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.(*Backend).baseURL; got != "https://mlflow.example.com/base" {
+		t.Errorf("baseURL = %q", got)
+	}
+}
@@ -0,0 +1,580 @@
+// Package opensearch implements provider.Backend for OpenSearch and
+// Elasticsearch. Maps: index patterns and data streams → groups, values of
+// a stream field → streams, documents → events.
+//
+// The defaults match documents written by a CloudWatch Logs subscription
+// filter (@log_stream, @timestamp, @message), and each can be changed for
+// other shippers, e.g. stream=kubernetes.pod_name.
+//
+// Requests can be signed with basic auth or, for AWS-managed domains, SigV4.
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/derricw/cwl/provider"
+)
+
+// Default field names, as written by CloudWatch Logs subscription filters.
+const (
+	DefaultStreamField  = "@log_stream"
+	DefaultTimeField    = "@timestamp"
+	DefaultMessageField = "@message"
+)
+
+// defaultPageSize is the number of documents requested per search.
+const defaultPageSize = 1000
+
+// maxStreams caps the terms aggregation that lists streams.
+const maxStreams = 10000
+
+// pitKeepAlive is how long a point in time outlives the page that last
+// used it.
+const pitKeepAlive = "1m"
+
+// Signer signs a request before it is sent. body is the request payload,
+// which SigV4 hashes.
+type Signer func(ctx context.Context, req *http.Request, body []byte) error
+
+type Backend struct {
+	baseURL string
+	client  *http.Client
+	// StreamField holds the stream name. It must be a keyword field, since
+	// streams are listed with a terms aggregation.
+	StreamField string
+	// TimeField is the document timestamp, used to sort events.
+	TimeField string
+	// MessageField is shown as the event message. Documents without it are
+	// shown as JSON.
+	MessageField string
+	// Sign, when set, signs every request (see BasicAuth and SigV4).
+	Sign     Signer
+	pageSize int
+}
+
+func init() {
+	for _, scheme := range []string{"opensearch+http", "opensearch+https", "elasticsearch+http", "elasticsearch+https"} {
+		provider.Register(scheme, open)
+	}
+}
+
+// open handles opensearch+https://[user:password@]host[:port][/prefix] with
+// optional query parameters stream, time and message (field names), and
+// sigv4=true with region and service (es, or aoss for serverless) for AWS
+// domains. The region defaults to the one in the domain's host name.
+func open(uri string, opts provider.OpenOptions) (provider.Backend, error) {
+	scheme, rest, _ := strings.Cut(uri, "://")
+	scheme = strings.ToLower(scheme)
+	scheme = strings.TrimPrefix(strings.TrimPrefix(scheme, "opensearch+"), "elasticsearch+")
+	u, err := url.Parse(scheme + "://" + rest)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	b := New(u.Scheme + "://" + u.Host + u.Path)
+	if v := query.Get("stream"); v != "" {
+		b.StreamField = v
+	}
+	if v := query.Get("time"); v != "" {
+		b.TimeField = v
+	}
+	if v := query.Get("message"); v != "" {
+		b.MessageField = v
+	}
+	switch {
+	case query.Get("sigv4") == "true":
+		region := query.Get("region")
+		if region == "" {
+			region = regionFromHost(u.Hostname())
+		}
+		service := query.Get("service")
+		if service == "" {
+			service = "es"
+		}
+		cfgOpts := []func(*config.LoadOptions) error{}
+		if opts.Profile != "" {
+			cfgOpts = append(cfgOpts, config.WithSharedConfigProfile(opts.Profile))
+		}
+		if region != "" {
+			cfgOpts = append(cfgOpts, config.WithRegion(region))
+		}
+		cfg, err := config.LoadDefaultConfig(context.TODO(), cfgOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		}
+		if cfg.Region == "" {
+			return nil, fmt.Errorf("no region for SigV4: add region= to the source")
+		}
+		b.Sign = SigV4(cfg.Credentials, cfg.Region, service)
+	case u.User != nil:
+		password, _ := u.User.Password()
+		b.Sign = BasicAuth(u.User.Username(), password)
+	}
+	return b, nil
+}
+
+// New creates a backend for the cluster at baseURL with the default fields.
+func New(baseURL string) *Backend {
+	return &Backend{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		client:       &http.Client{Timeout: 30 * time.Second},
+		StreamField:  DefaultStreamField,
+		TimeField:    DefaultTimeField,
+		MessageField: DefaultMessageField,
+		pageSize:     defaultPageSize,
+	}
+}
+
+// BasicAuth signs requests with a username and password.
+func BasicAuth(user, password string) Signer {
+	return func(ctx context.Context, req *http.Request, body []byte) error {
+		req.SetBasicAuth(user, password)
+		return nil
+	}
+}
+
+// SigV4 signs requests for AWS-managed domains. OpenSearch Serverless
+// (service aoss) also requires the payload hash as a signed header.
+func SigV4(credentials aws.CredentialsProvider, region, service string) Signer {
+	signer := v4.NewSigner()
+	return func(ctx context.Context, req *http.Request, body []byte) error {
+		creds, err := credentials.Retrieve(ctx)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(body)
+		hash := hex.EncodeToString(sum[:])
+		if service == "aoss" {
+			req.Header.Set("X-Amz-Content-Sha256", hash)
+		}
+		return signer.SignHTTP(ctx, creds, req, hash, service, region, time.Now())
+	}
+}
+
+// awsHost matches AWS-managed domain endpoints, capturing the region.
+var awsHost = regexp.MustCompile(`\.([a-z]{2}(?:-[a-z]+)+-\d)\.(?:es|aoss)\.amazonaws\.com$`)
+
+func regionFromHost(host string) string {
+	if m := awsHost.FindStringSubmatch(host); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// datedIndex matches indices rolled over by date, e.g. cwl-2025.06.01, so
+// they can be grouped under one pattern.
+var datedIndex = regexp.MustCompile(`^(.+[-_.])\d{4}([.-]\d{2}){1,2}$`)
+
+// API response types
+
+type catIndex struct {
+	Index string `json:"index"`
+}
+
+type dataStreamsResp struct {
+	DataStreams []struct {
+		Name string `json:"name"`
+	} `json:"data_streams"`
+}
+
+type searchResp struct {
+	PitID string `json:"pit_id"`
+	Hits  struct {
+		Hits []hit `json:"hits"`
+	} `json:"hits"`
+	Aggregations struct {
+		Streams struct {
+			Buckets []struct {
+				Key    interface{} `json:"key"`
+				Latest struct {
+					Value *float64 `json:"value"`
+				} `json:"latest"`
+			} `json:"buckets"`
+		} `json:"streams"`
+	} `json:"aggregations"`
+}
+
+type hit struct {
+	Source json.RawMessage `json:"_source"`
+	Sort   []interface{}   `json:"sort"`
+}
+
+// Backend interface implementation
+
+// FetchGroups lists data streams, and indices that aren't hidden or backing
+// a data stream. Indices rolled over by date are listed once, as a pattern.
+// Data streams are best-effort: clusters without them, or roles that can't
+// list them, answer 404 or 403, and only indices are listed.
+func (b *Backend) FetchGroups(ctx context.Context, pattern string) ([]provider.LogGroup, error) {
+	var streams dataStreamsResp
+	if err := b.do(ctx, http.MethodGet, "/_data_stream", nil, &streams); err != nil {
+		var apiErr *apiError
+		if !errors.As(err, &apiErr) || (apiErr.StatusCode != http.StatusNotFound && apiErr.StatusCode != http.StatusForbidden) {
+			return nil, err
+		}
+	}
+	var indices []catIndex
+	if err := b.do(ctx, http.MethodGet, "/_cat/indices?format=json&h=index", nil, &indices); err != nil {
+		return nil, err
+	}
+
+	var groups []provider.LogGroup
+	for _, ds := range streams.DataStreams {
+		groups = append(groups, provider.LogGroup{Name: ds.Name, Desc: "data stream"})
+	}
+	counts := map[string]int{}
+	for _, idx := range indices {
+		if strings.HasPrefix(idx.Index, ".") {
+			continue // hidden, system or data stream backing index
+		}
+		name := idx.Index
+		if m := datedIndex.FindStringSubmatch(name); m != nil {
+			name = m[1] + "*"
+		}
+		counts[name]++
+	}
+	for name, n := range counts {
+		desc := "index"
+		if strings.HasSuffix(name, "*") {
+			desc = fmt.Sprintf("%d indices", n)
+		}
+		groups = append(groups, provider.LogGroup{Name: name, Desc: desc})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+
+	if pattern == "" {
+		return groups, nil
+	}
+	var matched []provider.LogGroup
+	for _, g := range groups {
+		if strings.Contains(strings.ToLower(g.Name), strings.ToLower(pattern)) {
+			matched = append(matched, g)
+		}
+	}
+	return matched, nil
+}
+
+// FetchStreamsStreaming lists the values of the stream field, most recently
+// active first, with a terms aggregation.
+func (b *Backend) FetchStreamsStreaming(ctx context.Context, group string, callback func([]provider.LogStream) error) error {
+	query := map[string]interface{}{
+		"size": 0,
+		"aggs": map[string]interface{}{
+			"streams": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": b.StreamField,
+					"size":  maxStreams,
+					"order": map[string]string{"latest": "desc"},
+				},
+				"aggs": map[string]interface{}{
+					"latest": map[string]interface{}{"max": map[string]string{"field": b.TimeField}},
+				},
+			},
+		},
+	}
+	var resp searchResp
+	if err := b.search(ctx, group, query, &resp); err != nil {
+		return err
+	}
+	buckets := resp.Aggregations.Streams.Buckets
+	if len(buckets) == 0 {
+		return nil
+	}
+	streams := make([]provider.LogStream, len(buckets))
+	for i, bucket := range buckets {
+		streams[i] = provider.LogStream{Name: fmt.Sprint(bucket.Key)}
+		if bucket.Latest.Value != nil {
+			t := time.UnixMilli(int64(*bucket.Latest.Value))
+			streams[i].LastEventTime = &t
+		}
+	}
+	return callback(streams)
+}
+
+// FetchEventsStreaming pages through a stream's documents oldest first with
+// search_after. Documents with the same timestamp are ordered by
+// _shard_doc within a point in time, which, unlike _doc, is unique across
+// shards and the indices a pattern matches. Clusters without points in time
+// (e.g. OpenSearch Serverless) fall back to _doc, where such ties may repeat
+// or be skipped between pages.
+func (b *Backend) FetchEventsStreaming(ctx context.Context, group, stream string, callback func([]provider.LogEvent) error) error {
+	pit, err := b.openPIT(ctx, group)
+	if err != nil {
+		return err
+	}
+	if pit != nil {
+		defer b.closePIT(ctx, pit)
+	}
+	var after []interface{}
+	for {
+		query := b.eventsQuery(stream, nil, "asc", b.pageSize)
+		if after != nil {
+			query["search_after"] = after
+		}
+		var resp searchResp
+		if pit != nil {
+			query["pit"] = map[string]string{"id": pit.id, "keep_alive": pitKeepAlive}
+			query["sort"].([]interface{})[1] = map[string]string{"_shard_doc": "asc"}
+			if err := b.do(ctx, http.MethodPost, "/_search", query, &resp); err != nil {
+				return err
+			}
+			if resp.PitID != "" {
+				pit.id = resp.PitID
+			}
+		} else if err := b.search(ctx, group, query, &resp); err != nil {
+			return err
+		}
+		hits := resp.Hits.Hits
+		if len(hits) > 0 {
+			if err := callback(b.events(hits)); err != nil {
+				return err
+			}
+		}
+		if len(hits) < b.pageSize {
+			return nil
+		}
+		after = hits[len(hits)-1].Sort
+	}
+}
+
+func (b *Backend) FetchLastEvents(ctx context.Context, group, stream string, limit int) ([]provider.LogEvent, error) {
+	var resp searchResp
+	if err := b.search(ctx, group, b.eventsQuery(stream, nil, "desc", limit), &resp); err != nil {
+		return nil, err
+	}
+	events := b.events(resp.Hits.Hits)
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, nil
+}
+
+// FetchNewEvents polls for documents newer than since, up to one page.
+func (b *Backend) FetchNewEvents(ctx context.Context, group, stream string, since *time.Time) ([]provider.LogEvent, error) {
+	if since == nil {
+		return nil, nil
+	}
+	var resp searchResp
+	if err := b.search(ctx, group, b.eventsQuery(stream, since, "asc", b.pageSize), &resp); err != nil {
+		return nil, err
+	}
+	return b.events(resp.Hits.Hits), nil
+}
+
+// Capabilities: indices grow, can be saved and search has a query
+// language, but groups are filtered client-side.
+
+func (b *Backend) SupportsFollow() bool       { return true }
+func (b *Backend) SupportsQuery() bool        { return true }
+func (b *Backend) SupportsServerFilter() bool { return false }
+func (b *Backend) SupportsWrite() bool        { return true }
+
+// Helpers
+
+// pointInTime is an open point in time, in OpenSearch's or Elasticsearch's
+// flavour of the API.
+type pointInTime struct {
+	id      string
+	elastic bool
+}
+
+// openPIT opens a point in time over group, or returns nil when the cluster
+// has no point in time API.
+func (b *Backend) openPIT(ctx context.Context, group string) (*pointInTime, error) {
+	var opensearch struct {
+		PitID string `json:"pit_id"`
+	}
+	err := b.do(ctx, http.MethodPost, "/"+url.PathEscape(group)+"/_search/point_in_time?keep_alive="+pitKeepAlive, nil, &opensearch)
+	if err == nil {
+		return &pointInTime{id: opensearch.PitID}, nil
+	}
+	if !unsupported(err) {
+		return nil, err
+	}
+	var elastic struct {
+		ID string `json:"id"`
+	}
+	err = b.do(ctx, http.MethodPost, "/"+url.PathEscape(group)+"/_pit?keep_alive="+pitKeepAlive, nil, &elastic)
+	if err == nil {
+		return &pointInTime{id: elastic.ID, elastic: true}, nil
+	}
+	if !unsupported(err) {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// closePIT releases a point in time. It would expire anyway, so errors are
+// ignored, and it runs even when ctx is cancelled.
+func (b *Backend) closePIT(ctx context.Context, pit *pointInTime) {
+	ctx = context.WithoutCancel(ctx)
+	if pit.elastic {
+		b.do(ctx, http.MethodDelete, "/_pit", map[string]string{"id": pit.id}, nil)
+		return
+	}
+	b.do(ctx, http.MethodDelete, "/_search/point_in_time", map[string][]string{"pit_id": {pit.id}}, nil)
+}
+
+// unsupported reports whether err is a cluster rejecting an endpoint it
+// doesn't have.
+func unsupported(err error) bool {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusMethodNotAllowed:
+		return true
+	}
+	return false
+}
+
+// eventsQuery selects a stream's documents, optionally only those after
+// since, sorted by time. Sort values come back as epoch milliseconds.
+func (b *Backend) eventsQuery(stream string, since *time.Time, order string, size int) map[string]interface{} {
+	filters := []interface{}{
+		map[string]interface{}{"term": map[string]string{b.StreamField: stream}},
+	}
+	if since != nil {
+		filters = append(filters, map[string]interface{}{
+			"range": map[string]interface{}{
+				b.TimeField: map[string]interface{}{"gt": since.UnixMilli(), "format": "epoch_millis"},
+			},
+		})
+	}
+	return map[string]interface{}{
+		"size":  size,
+		"query": map[string]interface{}{"bool": map[string]interface{}{"filter": filters}},
+		"sort": []interface{}{
+			map[string]interface{}{b.TimeField: map[string]string{"order": order, "format": "epoch_millis"}},
+			map[string]string{"_doc": order},
+		},
+	}
+}
+
+// events converts hits, taking the timestamp from the first sort value and
+// the message from MessageField.
+func (b *Backend) events(hits []hit) []provider.LogEvent {
+	result := make([]provider.LogEvent, len(hits))
+	for i, h := range hits {
+		result[i].Message = b.message(h.Source)
+		if len(h.Sort) > 0 {
+			if ms, ok := epochMillis(h.Sort[0]); ok {
+				t := time.UnixMilli(ms)
+				result[i].Timestamp = &t
+			}
+		}
+	}
+	return result
+}
+
+// epochMillis reads a sort value, which is a number, or a string when the
+// sort asked for a format.
+func epochMillis(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return int64(v), true
+	case string:
+		var ms int64
+		_, err := fmt.Sscan(v, &ms)
+		return ms, err == nil
+	}
+	return 0, false
+}
+
+// message looks up MessageField in a document, following dots into nested
+// objects, and falls back to the whole document.
+func (b *Backend) message(source json.RawMessage) string {
+	var doc map[string]interface{}
+	if json.Unmarshal(source, &doc) != nil {
+		return string(source)
+	}
+	if v, ok := lookup(doc, b.MessageField); ok {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return string(source)
+}
+
+func lookup(doc map[string]interface{}, field string) (interface{}, bool) {
+	if v, ok := doc[field]; ok {
+		return v, true // field names may contain dots themselves
+	}
+	head, rest, ok := strings.Cut(field, ".")
+	if !ok {
+		return nil, false
+	}
+	nested, ok := doc[head].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookup(nested, rest)
+}
+
+func (b *Backend) search(ctx context.Context, group string, query map[string]interface{}, result interface{}) error {
+	return b.do(ctx, http.MethodPost, "/"+url.PathEscape(group)+"/_search", query, result)
+}
+
+func (b *Backend) do(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.Sign != nil {
+		if err := b.Sign(ctx, req, data); err != nil {
+			return err
+		}
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &apiError{StatusCode: resp.StatusCode, Status: resp.Status, Message: strings.TrimSpace(string(msg))}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// apiError is a non-200 response, kept whole so callers can tell a missing
+// or forbidden endpoint from other failures.
+type apiError struct {
+	StatusCode int
+	Status     string
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("opensearch API error: %s: %s", e.Status, e.Message)
+}
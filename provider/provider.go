@@ -3,28 +3,97 @@
 // interface to provide log data from different sources.
 package provider
 
-import "time"
+import (
+	"context"
+	"time"
+)
+
+// Optional fields are left zero (nil, 0 or "") by backends that don't know
+// them, so the TUI only shows what a source reports.
 
 type LogGroup struct {
 	Name string
 	Desc string // ARN for CloudWatch, experiment ID for MLflow, etc.
+	// Retention is how long events are kept, or 0 when they never expire
+	// or the backend doesn't say.
+	Retention   time.Duration
+	StoredBytes int64
+	CreatedTime *time.Time
+	// Tags are only filled by backends that list them with the group, which
+	// today is MLflow. CloudWatch leaves them nil: they take a
+	// ListTagsForResource call per group, so the commands fetch them only
+	// when selecting groups with --tag.
+	Tags map[string]string
+	// Metadata holds backend-specific details such as a KMS key or an
+	// MLflow artifact location, keyed as the backend names them.
+	Metadata map[string]string
 }
 
 type LogStream struct {
-	Name          string
-	LastEventTime *time.Time
+	Name           string
+	FirstEventTime *time.Time
+	LastEventTime  *time.Time
+	StoredBytes    int64
+	ARN            string
 }
 
 type LogEvent struct {
-	Message   string
-	Timestamp *time.Time
+	Message       string
+	Timestamp     *time.Time
+	IngestionTime *time.Time
+	// ID identifies the event within its source, when the source has IDs.
+	ID string
+	// Stream is the stream the event came from, which matters when one
+	// query spans several.
+	Stream string
+	// Fields holds structured values the backend parsed out of the event,
+	// such as an MLflow metric's step and value.
+	Fields map[string]string
 }
 
 // Backend abstracts log fetching so the TUI works with any log source.
+// Every method stops early and returns the context's error once ctx is
+// cancelled, so the TUI can abandon a load the user navigated away from.
 type Backend interface {
-	FetchGroups(pattern string) ([]LogGroup, error)
-	FetchStreamsStreaming(group string, callback func([]LogStream) error) error
-	FetchEventsStreaming(group, stream string, callback func([]LogEvent) error) error
-	FetchLastEvents(group, stream string, limit int) ([]LogEvent, error)
-	FetchNewEvents(group, stream string, since *time.Time) ([]LogEvent, error)
+	FetchGroups(ctx context.Context, pattern string) ([]LogGroup, error)
+	FetchStreamsStreaming(ctx context.Context, group string, callback func([]LogStream) error) error
+	FetchEventsStreaming(ctx context.Context, group, stream string, callback func([]LogEvent) error) error
+	FetchLastEvents(ctx context.Context, group, stream string, limit int) ([]LogEvent, error)
+	FetchNewEvents(ctx context.Context, group, stream string, since *time.Time) ([]LogEvent, error)
+}
+
+// Capabilities is implemented by backends whose features differ from
+// CloudWatch's, so the TUI can hide what a source can't do instead of
+// polling it for nothing.
+type Capabilities interface {
+	// SupportsFollow reports whether new streams and events can appear, and
+	// so whether the TUI should poll with FetchNewEvents.
+	SupportsFollow() bool
+	// SupportsQuery reports whether the source has a query language to search
+	// events with, rather than only listing them.
+	SupportsQuery() bool
+	// SupportsServerFilter reports whether FetchGroups filters by pattern on
+	// the server rather than needing every group listed.
+	SupportsServerFilter() bool
+	// SupportsWrite reports whether a stream's events can be written out to
+	// a file with the save binding.
+	SupportsWrite() bool
+}
+
+// defaultCapabilities keeps the behavior backends had before Capabilities
+// existed: events are polled and saved, and nothing else is assumed.
+type defaultCapabilities struct{}
+
+func (defaultCapabilities) SupportsFollow() bool       { return true }
+func (defaultCapabilities) SupportsQuery() bool        { return false }
+func (defaultCapabilities) SupportsServerFilter() bool { return false }
+func (defaultCapabilities) SupportsWrite() bool        { return true }
+
+// CapabilitiesOf returns the capabilities of b, or the defaults when b
+// doesn't declare any (including when b is nil).
+func CapabilitiesOf(b Backend) Capabilities {
+	if c, ok := b.(Capabilities); ok {
+		return c
+	}
+	return defaultCapabilities{}
 }
@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// OpenOptions carries command-line settings that a source URI may leave out.
+type OpenOptions struct {
+	Profile string // AWS profile, used when the URI doesn't name one
+}
+
+// Opener creates a backend from a source URI. It receives the whole URI,
+// since some sources (SageMaker ARNs) don't parse as URLs.
+type Opener func(uri string, opts OpenOptions) (Backend, error)
+
+var (
+	openersMu sync.RWMutex
+	openers   = map[string]Opener{}
+)
+
+// Register makes a backend available under a URI scheme. Backend packages
+// call it from init, so importing a backend is enough to enable it.
+// Registering a scheme twice panics, as it is a programming error.
+func Register(scheme string, open Opener) {
+	openersMu.Lock()
+	defer openersMu.Unlock()
+	scheme = strings.ToLower(scheme)
+	if _, dup := openers[scheme]; dup {
+		panic("provider: Register called twice for scheme " + scheme)
+	}
+	openers[scheme] = open
+}
+
+// Schemes returns the registered URI schemes, sorted.
+func Schemes() []string {
+	openersMu.RLock()
+	defer openersMu.RUnlock()
+	schemes := make([]string, 0, len(openers))
+	for scheme := range openers {
+		schemes = append(schemes, scheme)
+	}
+	slices.Sort(schemes)
+	return schemes
+}
+
+// Open creates the backend for a source URI such as
+// cloudwatch://profile@us-west-2 or file:///var/log/app, using the opener
+// registered for its scheme.
+func Open(uri string, opts OpenOptions) (Backend, error) {
+	scheme, _, ok := strings.Cut(uri, "://")
+	if !ok || scheme == "" {
+		return nil, fmt.Errorf("invalid source %q: expected scheme://..., with scheme one of %s", uri, strings.Join(Schemes(), ", "))
+	}
+	openersMu.RLock()
+	open, ok := openers[strings.ToLower(scheme)]
+	openersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown source scheme %q, must be one of %s", scheme, strings.Join(Schemes(), ", "))
+	}
+	return open(uri, opts)
+}
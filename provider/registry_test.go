@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+type nopBackend struct{ uri string }
+
+func (nopBackend) FetchGroups(ctx context.Context, pattern string) ([]LogGroup, error) {
+	return nil, nil
+}
+func (nopBackend) FetchStreamsStreaming(ctx context.Context, group string, callback func([]LogStream) error) error {
+	return nil
+}
+func (nopBackend) FetchEventsStreaming(ctx context.Context, group, stream string, callback func([]LogEvent) error) error {
+	return nil
+}
+func (nopBackend) FetchLastEvents(ctx context.Context, group, stream string, limit int) ([]LogEvent, error) {
+	return nil, nil
+}
+func (nopBackend) FetchNewEvents(ctx context.Context, group, stream string, since *time.Time) ([]LogEvent, error) {
+	return nil, nil
+}
+
+// TestOpenDispatchesByScheme verifies that Open hands the full URI to the
+// opener registered for its scheme, matching schemes case-insensitively.
+func TestOpenDispatchesByScheme(t *testing.T) {
+	Register("test+nop", func(uri string, opts OpenOptions) (Backend, error) {
+		return nopBackend{uri: uri + "|" + opts.Profile}, nil
+	})
+	b, err := Open("TEST+nop://a:b/c", OpenOptions{Profile: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.(nopBackend).uri; got != "TEST+nop://a:b/c|dev" {
+		t.Errorf("opener got %q", got)
+	}
+
+	for _, uri := range []string{"nope://x", "/var/log", "://x"} {
+		if _, err := Open(uri, OpenOptions{}); err == nil || !strings.Contains(err.Error(), "test+nop") {
+			t.Errorf("Open(%q) error = %v, want one listing the schemes", uri, err)
+		}
+	}
+}
+
+// TestCapabilitiesOfDefaults verifies that backends without declared
+// capabilities keep being polled and saved and claim nothing else.
+func TestCapabilitiesOfDefaults(t *testing.T) {
+	for _, b := range []Backend{nopBackend{}, nil} {
+		c := CapabilitiesOf(b)
+		if !c.SupportsFollow() || !c.SupportsWrite() || c.SupportsQuery() || c.SupportsServerFilter() {
+			t.Errorf("unexpected defaults for %T", b)
+		}
+	}
+}
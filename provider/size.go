@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var byteUnits = []string{"B", "KB", "MB", "GB", "TB", "PB"}
+
+// FormatBytes renders n in binary units, e.g. 1536 -> "1.5KB".
+func FormatBytes(n int64) string {
+	value := float64(n)
+	unit := 0
+	for value >= 1024 && unit < len(byteUnits)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%dB", n)
+	}
+	return fmt.Sprintf("%.1f%s", value, byteUnits[unit])
+}
+
+// ParseBytes is the inverse of FormatBytes. A bare number is bytes.
+func ParseBytes(s string) (int64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	for unit := len(byteUnits) - 1; unit >= 0; unit-- {
+		number, ok := strings.CutSuffix(upper, byteUnits[unit])
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil || value < 0 {
+			break
+		}
+		for range unit {
+			value *= 1024
+		}
+		return int64(value), nil
+	}
+	value, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q: expected a number with an optional B, KB, MB, GB, TB or PB suffix", s)
+	}
+	return value, nil
+}
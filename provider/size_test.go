@@ -0,0 +1,30 @@
+package provider
+
+import "testing"
+
+// TestParseBytes verifies parsing sizes with and without units, and the
+// binary units FormatBytes renders.
+func TestParseBytes(t *testing.T) {
+	cases := map[string]int64{
+		"0":     0,
+		"512":   512,
+		"2KB":   2048,
+		"1.5gb": 1536 * 1024 * 1024,
+		"10 MB": 10 * 1024 * 1024,
+		"100B":  100,
+	}
+	for in, want := range cases {
+		got, err := ParseBytes(in)
+		if err != nil || got != want {
+			t.Errorf("ParseBytes(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "MB", "-1", "1XB"} {
+		if _, err := ParseBytes(in); err == nil {
+			t.Errorf("expected error for %q", in)
+		}
+	}
+	if got := FormatBytes(1536); got != "1.5KB" {
+		t.Errorf("FormatBytes(1536) = %q", got)
+	}
+}